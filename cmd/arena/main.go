@@ -0,0 +1,292 @@
+// Command arena benchmarks AI strategies by playing them against each other
+// headlessly, without a server, and prints win, draw and loss tables. The
+// built-in difficulties take part by name, and external engines are added
+// with -engine just like on the server:
+//
+//	go build -o engine ./cmd/engine
+//	go run ./cmd/arena -players medium,hard,minimax -engine minimax=./engine -games 1000
+//
+// Every pair of players meets for -games games, taking turns to play X.
+//
+// "mcts" is the Monte Carlo tree search AI, limited per move by
+// -mcts-playouts and -mcts-time, and "mcts:N" is MCTS with N playouts and
+// no time limit, for comparing settings. With -size and -k the players
+// meet on a larger board, which only easy (random moves) and MCTS can
+// play:
+//
+//	go run ./cmd/arena -players easy,mcts:1000,mcts:10000 -size 7 -k 4 -games 100
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"tic-tac-toe/internal/domain/ai"
+	"tic-tac-toe/internal/domain/game"
+	"tic-tac-toe/internal/infrastructure/engine"
+	"time"
+)
+
+// Outcomes of a game, from one player's side.
+const (
+	win = iota
+	draw
+	loss
+)
+
+// strategy is one arena player: a built-in difficulty or an engine.
+type strategy struct {
+	name   string
+	engine ai.Engine // nil for the built-in difficulties
+	mcts   *ai.MCTS  // set for MCTS, which can also play larger boards
+
+	moves    int
+	elapsed  time.Duration
+	failures int
+}
+
+func (s *strategy) move(g *game.Game, player string) (int, error) {
+	started := time.Now()
+	defer func() {
+		s.moves++
+		s.elapsed += time.Since(started)
+	}()
+	if s.engine != nil {
+		return s.engine.Move(g, player)
+	}
+	return ai.Move(g, player, ai.Difficulty(s.name)), nil
+}
+
+// boardMove is move for a board other than 3x3, which only easy and MCTS
+// support.
+func (s *strategy) boardMove(b ai.Board, symbol byte) (int, error) {
+	started := time.Now()
+	defer func() {
+		s.moves++
+		s.elapsed += time.Since(started)
+	}()
+	if s.mcts != nil {
+		return s.mcts.Search(b, symbol)
+	}
+	empty := b.Empty()
+	return empty[rand.Intn(len(empty))], nil
+}
+
+// record tallies one player's games against one opponent, indexed by the
+// side played (0 for X, 1 for O) and the outcome.
+type record [2][3]int
+
+func (r *record) games() int {
+	return r.total(win) + r.total(draw) + r.total(loss)
+}
+
+func (r *record) total(outcome int) int {
+	return r[0][outcome] + r[1][outcome]
+}
+
+// score is the share of points won, counting a draw as half a win.
+func (r *record) score() float64 {
+	if r.games() == 0 {
+		return 0
+	}
+	return (float64(r.total(win)) + float64(r.total(draw))/2) / float64(r.games()) * 100
+}
+
+func main() {
+	players := flag.String("players", "easy,medium,hard", "comma-separated difficulties and engine names to compare")
+	games := flag.Int("games", 1000, "games played by each pair of players")
+	size := flag.Int("size", 3, "width and height of the board")
+	inARow := flag.Int("k", 3, "marks in a row needed to win")
+	mctsPlayouts := flag.Int("mcts-playouts", 20000, "random playouts per move of mcts (0 for no limit)")
+	mctsTime := flag.Duration("mcts-time", time.Second, "time allowed per move of mcts (0 for no limit)")
+	engineTimeout := flag.Duration("engine-timeout", 5*time.Second, "time an external engine may take to start or to make a move")
+	engines := make(map[string]string)
+	flag.Func("engine", "external AI engine as name=command (repeatable)", func(value string) error {
+		name, command, ok := strings.Cut(value, "=")
+		if !ok || name == "" || strings.TrimSpace(command) == "" {
+			return fmt.Errorf("want name=command")
+		}
+		engines[name] = command
+		return nil
+	})
+	flag.Parse()
+
+	// Games log every win and draw at info level; keep only problems.
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn})))
+
+	if *size < 3 || *inARow < 3 || *inARow > *size {
+		fatal(fmt.Errorf("want -size of at least 3 and -k between 3 and the size"))
+	}
+	classic := *size == 3 && *inARow == 3
+
+	var strategies []*strategy
+	for _, name := range strings.Split(*players, ",") {
+		name = strings.TrimSpace(name)
+		s := &strategy{name: name}
+		if command, ok := engines[name]; ok {
+			p, err := engine.New(name, strings.Fields(command), *engineTimeout)
+			if err != nil {
+				fatal(fmt.Errorf("engine %s: %w", name, err))
+			}
+			defer p.Close()
+			s.engine = p
+		} else if name == "mcts" || strings.HasPrefix(name, "mcts:") {
+			playouts, budget := *mctsPlayouts, *mctsTime
+			if n, ok := strings.CutPrefix(name, "mcts:"); ok {
+				var err error
+				if playouts, err = strconv.Atoi(n); err != nil {
+					fatal(fmt.Errorf("player %q: want mcts:<playouts>", name))
+				}
+				budget = 0
+			}
+			m, err := ai.NewMCTS(playouts, budget)
+			if err != nil {
+				fatal(fmt.Errorf("player %q: %w", name, err))
+			}
+			s.engine, s.mcts = m, m
+		} else if _, ok := ai.ParseDifficulty(name); !ok || name == "" {
+			fatal(fmt.Errorf("unknown player %q: want easy, medium, hard, mcts[:playouts] or an engine given with -engine", name))
+		}
+		if !classic && s.mcts == nil && name != string(ai.Easy) {
+			fatal(fmt.Errorf("player %q only plays 3x3; use easy or mcts on larger boards", name))
+		}
+		strategies = append(strategies, s)
+	}
+	if len(strategies) < 2 || *games < 1 {
+		fatal(fmt.Errorf("need at least two -players and one game"))
+	}
+	play := func(x, o *strategy) int {
+		if classic {
+			return playGame(x, o)
+		}
+		return playBoard(x, o, *size, *inARow)
+	}
+
+	// results[i][j] is strategies[i]'s record against strategies[j].
+	results := make([][]record, len(strategies))
+	for i := range results {
+		results[i] = make([]record, len(strategies))
+	}
+	started := time.Now()
+	for i := range strategies {
+		for j := i + 1; j < len(strategies); j++ {
+			for n := 0; n < *games; n++ {
+				a, b := i, j
+				if n%2 == 1 {
+					a, b = j, i
+				}
+				outcome := play(strategies[a], strategies[b])
+				results[a][b][0][outcome]++
+				results[b][a][1][2-outcome]++
+			}
+		}
+	}
+
+	pairs := len(strategies) * (len(strategies) - 1) / 2
+	fmt.Printf("%d games on %dx%d, %d in a row, in %s\n\n", pairs*(*games), *size, *size, *inARow, time.Since(started).Round(time.Millisecond))
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "PLAYER\tOPPONENT\tGAMES\tWINS\tDRAWS\tLOSSES\tSCORE\tAS X W/D/L\tAS O W/D/L\t")
+	for i, s := range strategies {
+		for j, opponent := range strategies {
+			if i == j {
+				continue
+			}
+			r := results[i][j]
+			fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%d\t%.1f%%\t%d/%d/%d\t%d/%d/%d\t\n",
+				s.name, opponent.name, r.games(), r.total(win), r.total(draw), r.total(loss), r.score(),
+				r[0][win], r[0][draw], r[0][loss], r[1][win], r[1][draw], r[1][loss])
+		}
+	}
+	w.Flush()
+
+	fmt.Println()
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "PLAYER\tGAMES\tWINS\tDRAWS\tLOSSES\tSCORE\tAVG MOVE\tFAILURES\t")
+	for i, s := range strategies {
+		var total record
+		for j := range strategies {
+			for side := range total {
+				for outcome := range total[side] {
+					total[side][outcome] += results[i][j][side][outcome]
+				}
+			}
+		}
+		average := time.Duration(0)
+		if s.moves > 0 {
+			average = s.elapsed / time.Duration(s.moves)
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%.1f%%\t%s\t%d\t\n",
+			s.name, total.games(), total.total(win), total.total(draw), total.total(loss), total.score(), average, s.failures)
+	}
+	w.Flush()
+}
+
+// playGame runs one game of 3x3 tic-tac-toe with x moving first and
+// returns x's outcome. A player whose move fails or is illegal forfeits
+// the game.
+func playGame(x, o *strategy) int {
+	g := game.NewGame("arena", []string{"X", "O"}, false)
+	sides := map[string]*strategy{"X": x, "O": o}
+	for !g.Over() {
+		player := g.CurrentTurn
+		s := sides[player]
+		position, err := s.move(g, player)
+		if err == nil {
+			err = g.MakeMove(slog.Default(), player, position)
+		}
+		if err != nil {
+			s.failures++
+			slog.Warn("move failed; game forfeited", "player", s.name, "symbol", player, "error", err)
+			if s == x {
+				return loss
+			}
+			return win
+		}
+	}
+	switch g.Winner {
+	case "X":
+		return win
+	case "O":
+		return loss
+	}
+	return draw
+}
+
+// playBoard is playGame on a size by size board where k in a row wins.
+func playBoard(x, o *strategy, size, k int) int {
+	b := ai.NewBoard(size, size, k)
+	sides := [2]*strategy{x, o}
+	symbols := [2]byte{'X', 'O'}
+	for turn := 0; len(b.Empty()) > 0; turn = 1 - turn {
+		s := sides[turn]
+		position, err := s.boardMove(b, symbols[turn])
+		if err == nil {
+			err = b.Play(position, symbols[turn])
+		}
+		if err != nil {
+			s.failures++
+			slog.Warn("move failed; game forfeited", "player", s.name, "symbol", string(symbols[turn]), "error", err)
+			if turn == 0 {
+				return loss
+			}
+			return win
+		}
+		if b.WinsAt(position) {
+			if turn == 0 {
+				return win
+			}
+			return loss
+		}
+	}
+	return draw
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "arena:", err)
+	os.Exit(2)
+}
@@ -0,0 +1,160 @@
+// Command bot is an example bot built on pkg/client. It plays a number of
+// games against the server's AI, in the two-player queue or against
+// players who challenge it, with a simple strategy: win if it can, block if it must, otherwise prefer the centre,
+// then corners, then edges.
+package main
+
+import (
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"os"
+	"tic-tac-toe/pkg/client"
+	"time"
+)
+
+var lines = [][3]int{
+	{0, 1, 2}, {3, 4, 5}, {6, 7, 8},
+	{0, 3, 6}, {1, 4, 7}, {2, 5, 8},
+	{0, 4, 8}, {2, 4, 6},
+}
+
+func main() {
+	addr := flag.String("addr", "localhost:5000", "server address")
+	username := flag.String("user", "bot", "username to log in as")
+	token := flag.String("token", "", "log in as a bot account with this token (from \"admin bot <name>\")")
+	useTLS := flag.Bool("tls", false, "connect with TLS")
+	insecure := flag.Bool("insecure", false, "with -tls, accept any server certificate")
+	mode := flag.String("mode", "ai", "game mode: ai, two-player, or challenges to wait for and accept challenges")
+	difficulty := flag.String("difficulty", "", "AI level: easy, medium, hard or the name of a server engine")
+	side := flag.String("as", "", "in ai mode, the mark to play: X, O or random (default X)")
+	games := flag.Int("games", 1, "number of games to play")
+	flag.Parse()
+
+	var c *client.Client
+	var err error
+	if *useTLS {
+		c, err = client.DialTLS(*addr, &tls.Config{InsecureSkipVerify: *insecure})
+	} else {
+		c, err = client.Dial(*addr)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to connect:", err)
+		os.Exit(1)
+	}
+	defer c.Close()
+	if *token != "" {
+		err = c.LoginBot(*username, *token)
+	} else {
+		err = c.Login(*username)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "login failed:", err)
+		os.Exit(1)
+	}
+
+	join := func() error {
+		switch *mode {
+		case "two-player":
+			return c.JoinTwoPlayer()
+		case "challenges":
+			return nil
+		}
+		if *side != "" {
+			return c.JoinAIAs(*difficulty, *side)
+		}
+		return c.JoinAI(*difficulty)
+	}
+	if err := join(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	tally := map[client.Outcome]int{}
+	played := 0
+	var turn *client.Turn
+	joining := *mode != "challenges"
+	inGame := false
+	for event := range c.Events() {
+		switch e := event.(type) {
+		case client.Turn:
+			turn = &e
+			c.Move(choose(e.Game.Board, e.Symbol) + 1)
+		case client.GameStarted:
+			joining = false
+			inGame = true
+		case client.Challenge:
+			if *mode == "challenges" && !inGame {
+				c.Accept(e.From)
+			} else {
+				c.Decline(e.From)
+			}
+		case client.Board:
+			turn = nil
+		case client.Result:
+			played++
+			tally[e.Outcome]++
+			fmt.Printf("game %d: %s\n", played, e.Outcome)
+		case client.GameEnded:
+			inGame = false
+			if played >= *games {
+				fmt.Printf("wins %d, losses %d, draws %d\n", tally[client.Win], tally[client.Loss], tally[client.Draw])
+				return
+			}
+			joining = *mode != "challenges"
+			join()
+		case client.Error:
+			// The server drops commands sent faster than its rate limit;
+			// wait and send the dropped command again.
+			fmt.Fprintln(os.Stderr, "server:", e.Text)
+			switch {
+			case turn != nil:
+				time.Sleep(time.Second)
+				c.Move(choose(turn.Game.Board, turn.Symbol) + 1)
+			case joining:
+				time.Sleep(time.Second)
+				join()
+			}
+		}
+	}
+	fmt.Fprintln(os.Stderr, "disconnected from server")
+	os.Exit(1)
+}
+
+// choose picks the 0-based position to play for symbol.
+func choose(board [9]string, symbol string) int {
+	opponent := "O"
+	if symbol == "O" {
+		opponent = "X"
+	}
+	for _, mark := range []string{symbol, opponent} {
+		if position, ok := completes(board, mark); ok {
+			return position
+		}
+	}
+	for _, position := range []int{4, 0, 2, 6, 8, 1, 3, 5, 7} {
+		if board[position] == " " {
+			return position
+		}
+	}
+	return 0
+}
+
+// completes finds the empty cell that would give mark three in a row.
+func completes(board [9]string, mark string) (int, bool) {
+	for _, line := range lines {
+		count, empty := 0, -1
+		for _, position := range line {
+			switch board[position] {
+			case mark:
+				count++
+			case " ":
+				empty = position
+			}
+		}
+		if count == 2 && empty >= 0 {
+			return empty, true
+		}
+	}
+	return 0, false
+}
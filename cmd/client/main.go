@@ -0,0 +1,155 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"tic-tac-toe/pkg/client"
+	"time"
+
+	"golang.org/x/term"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:5000", "server address")
+	username := flag.String("user", os.Getenv("USER"), "username to log in as")
+	askPassword := flag.Bool("password", false, "ask for the account's password and log in with it")
+	useTLS := flag.Bool("tls", false, "connect with TLS")
+	insecure := flag.Bool("insecure", false, "with -tls, accept any server certificate (e.g. the generated self-signed one)")
+	flag.Parse()
+
+	if *username == "" {
+		fmt.Fprintln(os.Stderr, "a username is required: use -user <name>")
+		os.Exit(2)
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		fmt.Fprintln(os.Stderr, "the client needs an interactive terminal")
+		os.Exit(2)
+	}
+
+	password := ""
+	if *askPassword {
+		fmt.Fprint(os.Stderr, "Password: ")
+		secret, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "failed to read the password:", err)
+			os.Exit(1)
+		}
+		password = string(secret)
+	}
+
+	c, err := dial(*addr, *useTLS, *insecure)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to connect:", err)
+		os.Exit(1)
+	}
+	defer c.Close()
+
+	login := func() error { return c.Login(*username) }
+	if *askPassword {
+		login = func() error { return c.LoginPassword(*username, password) }
+	}
+	if err := login(); err != nil {
+		fmt.Fprintln(os.Stderr, "login failed:", err)
+		os.Exit(1)
+	}
+	ui := newUI(c.Username())
+
+	state, err := term.MakeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to set up the terminal:", err)
+		os.Exit(1)
+	}
+	ui.open()
+	err = run(c, ui)
+	ui.close()
+	term.Restore(int(os.Stdin.Fd()), state)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func dial(addr string, useTLS, insecure bool) (*client.Client, error) {
+	if useTLS {
+		return client.DialTLS(addr, &tls.Config{InsecureSkipVerify: insecure})
+	}
+	return client.Dial(addr)
+}
+
+// run is the client's main loop: it applies server events and key presses
+// to the UI and redraws it, ticking once a second for the clocks.
+func run(c *client.Client, ui *ui) error {
+	keys := make(chan []byte)
+	go readKeys(keys)
+	c.RequestLeaderboard(10)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		ui.draw()
+		select {
+		case event, ok := <-c.Events():
+			if !ok {
+				return errors.New("disconnected from server")
+			}
+			ui.handle(event)
+			if _, over := event.(client.Result); over {
+				c.RequestLeaderboard(10)
+			}
+		case key, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			commands, quit := ui.key(key)
+			for _, command := range commands {
+				c.Send(command)
+			}
+			if quit {
+				return nil
+			}
+		case <-ticker.C:
+		}
+	}
+}
+
+// readKeys sends each chunk of raw input from the terminal.
+func readKeys(keys chan<- []byte) {
+	buf := make([]byte, 64)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			close(keys)
+			return
+		}
+		keys <- append([]byte(nil), buf[:n]...)
+	}
+}
+
+// command translates a line typed in the input box into a server command.
+// Lines starting with / are commands; anything else is chat.
+func command(line string) (string, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return "", false
+	}
+	if !strings.HasPrefix(line, "/") {
+		return "say " + line, false
+	}
+	fields := strings.Fields(line[1:])
+	if len(fields) == 0 {
+		return "", false
+	}
+	switch fields[0] {
+	case "quit", "exit":
+		return "exit", true
+	case "two", "play":
+		return "join two-player", false
+	case "ai":
+		return strings.TrimSpace("join ai " + strings.Join(fields[1:], " ")), false
+	}
+	return strings.Join(fields, " "), false
+}
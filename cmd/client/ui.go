@@ -0,0 +1,346 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"tic-tac-toe/pkg/client"
+	"tic-tac-toe/pkg/protocol"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+const (
+	reset       = "\x1b[0m"
+	bold        = "\x1b[1m"
+	dim         = "\x1b[2m"
+	reverse     = "\x1b[7m"
+	colorX      = "\x1b[1;31m"
+	colorO      = "\x1b[1;34m"
+	winningLine = "\x1b[1;97;42m"
+
+	// logSize is how many lines of messages and chat are kept.
+	logSize = 200
+	// paneWidth is the width of the lobby and leaderboard panes.
+	paneWidth = 24
+)
+
+// ui holds everything the client shows and draws it full screen.
+type ui struct {
+	username  string
+	game      *protocol.Game
+	gameAt    time.Time // when game was received, for the clock
+	gameEnded bool
+	lobby     []protocol.LobbyPlayer
+	standings []protocol.Standing
+	log       []string
+	input     []rune
+	cursor    int // selected board position, 0 to 8
+	out       *bufio.Writer
+}
+
+func newUI(username string) *ui {
+	return &ui{username: username, cursor: 4, out: bufio.NewWriter(os.Stdout)}
+}
+
+// open switches to the alternate screen so the shell is restored on exit.
+func (u *ui) open() {
+	u.out.WriteString("\x1b[?1049h")
+	u.out.Flush()
+}
+
+func (u *ui) close() {
+	u.out.WriteString(reset + "\x1b[?25h\x1b[?1049l")
+	u.out.Flush()
+}
+
+// handle applies a server event.
+func (u *ui) handle(event client.Event) {
+	switch e := event.(type) {
+	case client.Message:
+		u.addLog(e.Text)
+	case client.Error:
+		u.addLog("! " + e.Text)
+	case client.Chat:
+		u.addLog(fmt.Sprintf("[%s] %s: %s", e.Scope, e.From, e.Text))
+	case client.Challenge:
+		u.addLog(fmt.Sprintf("%s challenges you to a game. Type /accept %s or /decline %s", e.From, e.From, e.From))
+	case client.GameStarted:
+		u.cursor = 4
+	case client.Board:
+		u.game = e.Game
+		u.gameAt = time.Now()
+		u.gameEnded = false
+	case client.GameEnded:
+		if u.game != nil && u.game.ID == e.GameID {
+			u.gameEnded = true
+		}
+	case client.Lobby:
+		u.lobby = e.Players
+	case client.Leaderboard:
+		u.standings = e.Standings
+	}
+}
+
+func (u *ui) addLog(text string) {
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		u.log = append(u.log, stripControl(line))
+	}
+	if len(u.log) > logSize {
+		u.log = u.log[len(u.log)-logSize:]
+	}
+}
+
+// stripControl removes control characters from text received from the
+// server, such as escape sequences in another player's chat, so they cannot
+// drive the terminal.
+func stripControl(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// playing reports whether there is a game the player can still move in.
+func (u *ui) playing() bool {
+	return u.game != nil && !u.game.Over() && !u.gameEnded
+}
+
+// key applies a chunk of terminal input and returns the commands to send
+// and whether the client should quit.
+func (u *ui) key(input []byte) ([]string, bool) {
+	var commands []string
+	for len(input) > 0 {
+		b := input[0]
+		switch {
+		case b == 0x03 || b == 0x04: // ctrl-c, ctrl-d
+			return append(commands, "exit"), true
+		case b == 0x1b && len(input) >= 3 && (input[1] == '[' || input[1] == 'O'):
+			u.arrow(input[2])
+			input = input[3:]
+			continue
+		case b == '\r' || b == '\n':
+			if len(u.input) == 0 {
+				if u.playing() {
+					commands = append(commands, fmt.Sprintf("move %d", u.cursor+1))
+				}
+			} else {
+				cmd, quit := command(string(u.input))
+				u.input = u.input[:0]
+				if cmd != "" {
+					commands = append(commands, cmd)
+				}
+				if quit {
+					return commands, true
+				}
+			}
+		case b == 0x7f || b == 0x08:
+			if len(u.input) > 0 {
+				u.input = u.input[:len(u.input)-1]
+			}
+		case b >= '1' && b <= '9' && len(u.input) == 0 && u.playing():
+			u.cursor = int(b - '1')
+			commands = append(commands, fmt.Sprintf("move %c", b))
+		case b >= 0x20:
+			r, size := utf8.DecodeRune(input)
+			u.input = append(u.input, r)
+			input = input[size:]
+			continue
+		}
+		input = input[1:]
+	}
+	return commands, false
+}
+
+// arrow moves the board cursor.
+func (u *ui) arrow(key byte) {
+	row, col := u.cursor/3, u.cursor%3
+	switch key {
+	case 'A':
+		row = (row + 2) % 3
+	case 'B':
+		row = (row + 1) % 3
+	case 'C':
+		col = (col + 1) % 3
+	case 'D':
+		col = (col + 2) % 3
+	}
+	u.cursor = row*3 + col
+}
+
+// draw redraws the whole screen: a title bar, the board and clock on the
+// left, the lobby and leaderboard panes on the right, the message and chat
+// log below and the input line at the bottom.
+func (u *ui) draw() {
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || width <= 0 || height <= 0 {
+		width, height = 80, 24
+	}
+	var sb strings.Builder
+	sb.WriteString("\x1b[?25l\x1b[H\x1b[J")
+	at := func(row, col int, text string) {
+		fmt.Fprintf(&sb, "\x1b[%d;%dH%s", row, col, text)
+	}
+
+	at(1, 1, reverse+pad(" Tic Tac Toe - "+u.username, width)+reset)
+
+	at(3, 3, bold+"Board"+reset)
+	for row := 0; row < 3; row++ {
+		var line strings.Builder
+		for col := 0; col < 3; col++ {
+			if col > 0 {
+				line.WriteString("|")
+			}
+			line.WriteString(u.cell(row*3 + col))
+		}
+		at(4+row*2, 3, line.String())
+		if row < 2 {
+			at(5+row*2, 3, "---+---+---")
+		}
+	}
+	for i, line := range u.gameStatus() {
+		at(10+i, 3, truncate(line, 28))
+	}
+
+	if width >= 32+paneWidth {
+		at(3, 32, bold+"Lobby"+reset)
+		for i, p := range u.lobby {
+			if i >= 8 {
+				break
+			}
+			at(4+i, 32, truncate(p.Username+" ("+p.Status+")", paneWidth))
+		}
+	}
+	if width >= 32+2*paneWidth+2 {
+		col := 34 + paneWidth
+		at(3, col, bold+"Leaderboard"+reset)
+		for i, st := range u.standings {
+			if i >= 8 {
+				break
+			}
+			at(4+i, col, truncate(fmt.Sprintf("%d. %s %d", st.Rank, st.Username, st.Points), paneWidth))
+		}
+	}
+
+	logTop, logBottom := 14, height-2
+	if logBottom >= logTop {
+		lines := u.log
+		if visible := logBottom - logTop + 1; len(lines) > visible {
+			lines = lines[len(lines)-visible:]
+		}
+		for i, line := range lines {
+			at(logTop+i, 1, truncate(line, width))
+		}
+	}
+	at(height-1, 1, dim+truncate("arrows/1-9 pick a cell, Enter moves | /two, /ai [easy|medium|hard], /lobby, /quit | other text is chat", width)+reset)
+	input := string(u.input)
+	if n := utf8.RuneCountInString(input); n > width-3 {
+		input = string(u.input[n-(width-3):])
+	}
+	at(height, 1, "> "+input+"\x1b[?25h")
+
+	u.out.WriteString(sb.String())
+	u.out.Flush()
+}
+
+// cell draws one board square, three columns wide.
+func (u *ui) cell(index int) string {
+	if u.game == nil {
+		return dim + fmt.Sprintf(" %d ", index+1) + reset
+	}
+	winning := false
+	for _, position := range u.game.WinningLine {
+		if position == index+1 {
+			winning = true
+		}
+	}
+	style := ""
+	mark := u.game.Board[index]
+	switch {
+	case mark == " ":
+		style = dim
+		mark = fmt.Sprint(index + 1)
+	case winning:
+		style = winningLine
+	case mark == "X":
+		style = colorX
+	default:
+		style = colorO
+	}
+	if index == u.cursor && u.playing() {
+		style += reverse
+	}
+	return style + " " + mark + " " + reset
+}
+
+// gameStatus describes the current game: who is playing whom and either
+// whose turn it is with their clock or how the game ended.
+func (u *ui) gameStatus() []string {
+	if u.game == nil {
+		return []string{"No game. Type /two or /ai"}
+	}
+	g := u.game
+	opponent, playing := "", false
+	for _, p := range g.Players {
+		if p != u.username {
+			opponent = p
+		} else {
+			playing = true
+		}
+	}
+	lines := []string{fmt.Sprintf("You are %s vs %s", g.SymbolOf(u.username), opponent)}
+	if !playing {
+		lines[0] = fmt.Sprintf("Watching %s vs %s", g.Players[0], g.Players[1])
+	}
+	switch {
+	case g.Winner == u.username:
+		lines = append(lines, "You win!")
+	case g.Winner != "":
+		lines = append(lines, g.Winner+" wins!")
+	case g.Draw:
+		lines = append(lines, "It's a draw!")
+	case u.gameEnded:
+		lines = append(lines, "Game over")
+	default:
+		turn := g.CurrentTurn + " to move"
+		if g.CurrentTurn == u.username {
+			turn = "Your move"
+		}
+		if g.TurnRemainingMS > 0 {
+			remaining := time.Duration(g.TurnRemainingMS)*time.Millisecond - time.Since(u.gameAt)
+			turn += "  " + clock(remaining)
+		}
+		lines = append(lines, turn)
+	}
+	return lines
+}
+
+// clock formats a remaining duration as m:ss.
+func clock(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	seconds := int(d.Round(time.Second).Seconds())
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
+
+func truncate(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	return string([]rune(s)[:width])
+}
+
+func pad(s string, width int) string {
+	s = truncate(s, width)
+	return s + strings.Repeat(" ", width-utf8.RuneCountInString(s))
+}
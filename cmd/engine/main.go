@@ -0,0 +1,106 @@
+// Command engine is an example external AI engine. It speaks the server's
+// engine protocol on stdin and stdout and plays perfectly with minimax.
+// Build it and register it with the server:
+//
+//	go build -o engine ./cmd/engine
+//	server -engine minimax=./engine
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var lines = [][3]int{
+	{0, 1, 2}, {3, 4, 5}, {6, 7, 8},
+	{0, 3, 6}, {1, 4, 7}, {2, 5, 8},
+	{0, 4, 8}, {2, 4, 6},
+}
+
+func main() {
+	scanner := bufio.NewScanner(os.Stdin)
+	var board [9]byte
+	var symbol byte
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "ttt":
+			fmt.Println("id name minimax")
+			fmt.Println("ready")
+		case "position":
+			if len(fields) != 3 || len(fields[1]) != 9 || len(fields[2]) != 1 {
+				fmt.Fprintln(os.Stderr, "bad position:", scanner.Text())
+				continue
+			}
+			copy(board[:], fields[1])
+			symbol = fields[2][0]
+		case "go":
+			fmt.Printf("bestmove %d\n", bestMove(&board, symbol)+1)
+		case "quit":
+			return
+		}
+	}
+}
+
+func other(symbol byte) byte {
+	if symbol == 'X' {
+		return 'O'
+	}
+	return 'X'
+}
+
+func won(board *[9]byte, symbol byte) bool {
+	for _, line := range lines {
+		if board[line[0]] == symbol && board[line[1]] == symbol && board[line[2]] == symbol {
+			return true
+		}
+	}
+	return false
+}
+
+// bestMove returns the 0-based position with the best outcome for symbol,
+// preferring faster wins.
+func bestMove(board *[9]byte, symbol byte) int {
+	best, bestScore := -1, -100
+	for i := range board {
+		if board[i] != '-' {
+			continue
+		}
+		board[i] = symbol
+		score := -minimax(board, other(symbol), 1)
+		board[i] = '-'
+		if score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	return best
+}
+
+// minimax scores the position for symbol, who is about to move.
+func minimax(board *[9]byte, symbol byte, depth int) int {
+	if won(board, other(symbol)) {
+		return depth - 10
+	}
+	best, moved := -100, false
+	for i := range board {
+		if board[i] != '-' {
+			continue
+		}
+		moved = true
+		board[i] = symbol
+		score := -minimax(board, other(symbol), depth+1)
+		board[i] = '-'
+		if score > best {
+			best = score
+		}
+	}
+	if !moved {
+		return 0
+	}
+	return best
+}
@@ -1,20 +1,180 @@
 package main
 
 import (
-	"log"
+	"bufio"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"tic-tac-toe/internal/audit"
+	"tic-tac-toe/internal/domain/scoring"
 	"tic-tac-toe/internal/infrastructure/network"
 	"tic-tac-toe/internal/infrastructure/repository"
+	"tic-tac-toe/internal/metrics"
 )
 
 func main() {
+	config := network.DefaultConfig()
+	flag.StringVar(&config.Addr, "addr", config.Addr, "plaintext address to listen on (empty disables it)")
+	flag.StringVar(&config.TLSAddr, "tls-addr", "", "TLS address to listen on, e.g. :5443 (disabled if empty)")
+	flag.StringVar(&config.TLSCertFile, "tls-cert", "", "TLS certificate file (a self-signed certificate is generated if empty)")
+	flag.StringVar(&config.TLSKeyFile, "tls-key", "", "TLS private key file")
+	flag.StringVar(&config.TelnetAddr, "telnet-addr", "", "telnet address to listen on, e.g. :2323 (disabled if empty)")
+	flag.StringVar(&config.SSHAddr, "ssh-addr", "", "SSH address to listen on, e.g. :2222 (disabled if empty)")
+	flag.StringVar(&config.SSHHostKeyFile, "ssh-host-key", config.SSHHostKeyFile, "SSH host key file (generated if it does not exist)")
+	flag.DurationVar(&config.UsernameTimeout, "username-timeout", config.UsernameTimeout, "disconnect clients that do not enter a username within this time (0 disables)")
+	flag.DurationVar(&config.LobbyTimeout, "lobby-timeout", config.LobbyTimeout, "disconnect players idle outside their turn for this long (0 disables)")
+	flag.DurationVar(&config.TurnTimeout, "turn-timeout", config.TurnTimeout, "disconnect players who do not move within this time on their turn (0 disables)")
+	flag.DurationVar(&config.IdleWarning, "idle-warning", config.IdleWarning, "warn idle clients this long before disconnecting them")
+	flag.IntVar(&config.MaxConnections, "max-connections", config.MaxConnections, "maximum open connections (0 means unlimited)")
+	flag.IntVar(&config.MaxConnectionsPerIP, "max-connections-per-ip", config.MaxConnectionsPerIP, "maximum open connections from one IP address (0 means unlimited)")
+	flag.Float64Var(&config.CommandRate, "command-rate", config.CommandRate, "commands per second allowed per connection (0 disables)")
+	flag.IntVar(&config.CommandBurst, "command-burst", config.CommandBurst, "burst of commands allowed per connection")
+	flag.Float64Var(&config.IPCommandRate, "ip-command-rate", config.IPCommandRate, "commands per second allowed across all connections from one IP (0 disables)")
+	flag.IntVar(&config.IPCommandBurst, "ip-command-burst", config.IPCommandBurst, "burst of commands allowed across all connections from one IP")
+	flag.Func("engine", "external AI engine as name=command, played with \"join ai <name>\" (repeatable)", func(value string) error {
+		name, command, ok := strings.Cut(value, "=")
+		if !ok || name == "" || strings.TrimSpace(command) == "" {
+			return fmt.Errorf("want name=command")
+		}
+		if config.Engines == nil {
+			config.Engines = make(map[string]string)
+		}
+		config.Engines[name] = command
+		return nil
+	})
+	flag.DurationVar(&config.EngineTimeout, "engine-timeout", config.EngineTimeout, "time an external engine may take to start or to make a move")
+	flag.IntVar(&config.MCTSPlayouts, "mcts-playouts", config.MCTSPlayouts, "random playouts per move of the mcts AI (0 for no limit)")
+	flag.DurationVar(&config.MCTSBudget, "mcts-time", config.MCTSBudget, "time allowed per move of the mcts AI (0 for no limit)")
+	flag.DurationVar(&config.ExhibitionMoveDelay, "exhibition-delay", config.ExhibitionMoveDelay, "pause before each move of an AI-versus-AI exhibition game")
+	scoringPath := flag.String("scoring", "", "path to a JSON file with scoring rules")
+	bansPath := flag.String("bans", "bans.json", "path to the persisted ban list")
+	menacePath := flag.String("menace", "menace.json", "path to the persisted memory of the learning menace AI")
+	admins := flag.String("admins", "", "comma-separated usernames granted the admin role when they log in")
+	metricsAddr := flag.String("metrics-addr", "", "address to serve Prometheus metrics on /metrics (disabled if empty)")
+	logLevel := flag.String("log-level", "info", "minimum log level: debug, info, warn or error")
+	logFormat := flag.String("log-format", "text", "log output format: text or json")
+	auditPath := flag.String("audit-log", "audit.log", "path to the JSON lines audit log")
+	auditMaxSize := flag.Int64("audit-max-size", 10, "size in MB at which the audit log is rotated")
+	auditMaxFiles := flag.Int("audit-max-files", 5, "number of rotated audit log files to keep")
+	flag.Parse()
+
+	logger, err := newLogger(*logLevel, *logFormat)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	slog.SetDefault(logger)
+
+	rules := scoring.Default()
+	if *scoringPath != "" {
+		rules, err = scoring.Load(*scoringPath)
+		if err != nil {
+			fatal("failed to load scoring rules", err)
+		}
+	}
 
 	userRepo := repository.NewInMemoryUserRepository()
 	gameRepo := repository.NewInMemoryGameRepository()
+	resultRepo := repository.NewInMemoryResultRepository()
+	seasonRepo := repository.NewInMemorySeasonRepository()
+	banRepo, err := repository.NewFileBanRepository(*bansPath)
+	if err != nil {
+		fatal("failed to load ban list", err)
+	}
+	menaceRepo := repository.NewFileMenaceRepository(*menacePath)
+
+	if *auditMaxFiles < 1 || *auditMaxSize < 1 {
+		fatal("invalid audit log rotation settings", fmt.Errorf("-audit-max-size and -audit-max-files must be at least 1"))
+	}
+	auditLog, err := audit.Open(*auditPath, *auditMaxSize<<20, *auditMaxFiles)
+	if err != nil {
+		fatal("failed to open audit log", err)
+	}
+	defer auditLog.Close()
+
+	if *admins != "" {
+		config.Admins = strings.Split(*admins, ",")
+	}
+	server := network.NewTCPServer(config, userRepo, gameRepo, resultRepo, seasonRepo, banRepo, menaceRepo, rules, auditLog)
+
+	go operatorConsole(server)
 
-	server := network.NewTCPServer(":5000", userRepo, gameRepo)
+	if *metricsAddr != "" {
+		go serveMetrics(*metricsAddr)
+	}
 
-	log.Println("Server started on :5000")
+	slog.Info("server started", "addr", config.Addr, "tls_addr", config.TLSAddr, "telnet_addr", config.TelnetAddr, "ssh_addr", config.SSHAddr)
 	if err := server.Start(); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+		fatal("server stopped", err)
+	}
+}
+
+func newLogger(level, format string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid -log-level %q", level)
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+	switch format {
+	case "text":
+		return slog.New(slog.NewTextHandler(os.Stderr, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(os.Stderr, opts)), nil
+	}
+	return nil, fmt.Errorf("invalid -log-format %q", format)
+}
+
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+func serveMetrics(addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	slog.Info("serving metrics", "addr", addr, "path", "/metrics")
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		slog.Error("metrics server stopped", "error", err)
+	}
+}
+
+// operatorConsole reads operator commands from the server's standard input.
+func operatorConsole(server *network.TCPServer) {
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch {
+		case len(fields) == 2 && fields[0] == "season" && fields[1] == "start":
+			if err := server.StartSeason(); err != nil {
+				slog.Error("failed to start season", "error", err)
+			}
+		case len(fields) == 2 && fields[0] == "grant-admin":
+			if err := server.SetAdmin(fields[1], true); err != nil {
+				slog.Error("failed to grant admin", "username", fields[1], "error", err)
+			}
+		case len(fields) == 2 && fields[0] == "revoke-admin":
+			if err := server.SetAdmin(fields[1], false); err != nil {
+				slog.Error("failed to revoke admin", "username", fields[1], "error", err)
+			}
+		case len(fields) == 3 && fields[0] == "set-password":
+			if err := server.SetPassword(fields[1], fields[2]); err != nil {
+				slog.Error("failed to set password", "username", fields[1], "error", err)
+			}
+		case len(fields) == 2 && fields[0] == "add-bot":
+			token, err := server.RegisterBot(fields[1])
+			if err != nil {
+				slog.Error("failed to add bot", "username", fields[1], "error", err)
+				continue
+			}
+			fmt.Printf("Bot %s can now log in with: bot %s %s\n", fields[1], fields[1], token)
+		default:
+			fmt.Println("Operator commands: season start, grant-admin <user>, revoke-admin <user>, add-bot <name>, set-password <user> <password>")
+		}
 	}
 }
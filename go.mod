@@ -1,3 +1,10 @@
 module tic-tac-toe
 
-go 1.22.2
+go 1.23.0
+
+require (
+	golang.org/x/crypto v0.40.0
+	golang.org/x/term v0.33.0
+)
+
+require golang.org/x/sys v0.34.0 // indirect
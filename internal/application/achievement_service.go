@@ -0,0 +1,71 @@
+package application
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"tic-tac-toe/internal/domain/achievement"
+	"tic-tac-toe/internal/domain/game"
+	"tic-tac-toe/internal/domain/user"
+	"time"
+)
+
+// AchievementService evaluates achievement rules and queues announcements
+// for newly unlocked achievements until they are delivered to the player.
+type AchievementService struct {
+	userRepo user.UserRepository
+	pending  map[string][]achievement.Achievement
+	mu       sync.Mutex
+}
+
+func NewAchievementService(userRepo user.UserRepository) *AchievementService {
+	return &AchievementService{
+		userRepo: userRepo,
+		pending:  make(map[string][]achievement.Achievement),
+	}
+}
+
+// Evaluate checks every rule for u after g finished (g may be nil), saves
+// the user and queues announcements for anything newly unlocked.
+func (s *AchievementService) Evaluate(u *user.User, g *game.Game) {
+	unlocked := achievement.Evaluate(achievement.Context{User: u, Game: g})
+	if len(unlocked) == 0 {
+		return
+	}
+	s.userRepo.Save(u)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending[u.Username] = append(s.pending[u.Username], unlocked...)
+}
+
+// TakeAnnouncements returns and clears the unlock messages waiting for
+// username.
+func (s *AchievementService) TakeAnnouncements(username string) []string {
+	s.mu.Lock()
+	unlocked := s.pending[username]
+	delete(s.pending, username)
+	s.mu.Unlock()
+	var messages []string
+	for _, a := range unlocked {
+		messages = append(messages, fmt.Sprintf("Achievement unlocked: %s - %s", a.Name, a.Description))
+	}
+	return messages
+}
+
+// GetAchievements lists every achievement and whether username has it.
+func (s *AchievementService) GetAchievements(username string) (string, error) {
+	u, err := s.userRepo.FindByUsername(username)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Achievements (%d/%d):\n", len(u.Achievements), len(achievement.Rules))
+	for _, rule := range achievement.Rules {
+		if unlockedAt, ok := u.Achievements[rule.ID]; ok {
+			fmt.Fprintf(&sb, "[x] %s - %s (%s)\n", rule.Name, rule.Description, unlockedAt.Format(time.DateOnly))
+		} else {
+			fmt.Fprintf(&sb, "[ ] %s - %s\n", rule.Name, rule.Description)
+		}
+	}
+	return sb.String(), nil
+}
@@ -3,130 +3,504 @@ package application
 import (
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"tic-tac-toe/internal/audit"
 	"tic-tac-toe/internal/domain/ai"
 	"tic-tac-toe/internal/domain/game"
+	"tic-tac-toe/internal/domain/result"
+	"tic-tac-toe/internal/domain/scoring"
 	"tic-tac-toe/internal/domain/user"
+	"tic-tac-toe/internal/metrics"
 	"time"
 )
 
+// MaxExhibitions caps the exhibition games running at once, since each one
+// keeps the AIs, and possibly engine processes, busy.
+const MaxExhibitions = 5
+
+// ModeExhibition is the mode of exhibition games in metrics and game
+// events. They are never recorded as results.
+const ModeExhibition = "exhibition"
+
 // GameService manages game-related operations.
 type GameService struct {
-	gameRepo game.GameRepository
-	userRepo user.UserRepository
+	gameRepo     game.GameRepository
+	userRepo     user.UserRepository
+	resultRepo   result.ResultRepository
+	achievements *AchievementService
+	rules        *scoring.Rules
+	auditLog     *audit.Log
+	engines      map[string]ai.Engine
+
+	mu sync.Mutex
+	// lastGames holds each player's most recent game once it is deleted,
+	// for review.
+	lastGames map[string]*game.Game
+	// gameLocks serializes the changes to each game in play, which are
+	// made to a copy found in the repository and then saved.
+	gameLocks map[string]*sync.Mutex
 }
 
-func NewGameService(gameRepo game.GameRepository, userRepo user.UserRepository) *GameService {
+func NewGameService(gameRepo game.GameRepository, userRepo user.UserRepository, resultRepo result.ResultRepository, achievements *AchievementService, rules *scoring.Rules, auditLog *audit.Log) *GameService {
 	return &GameService{
-		gameRepo: gameRepo,
-		userRepo: userRepo,
+		gameRepo:     gameRepo,
+		userRepo:     userRepo,
+		resultRepo:   resultRepo,
+		achievements: achievements,
+		rules:        rules,
+		auditLog:     auditLog,
+		engines:      make(map[string]ai.Engine),
+		lastGames:    make(map[string]*game.Game),
+		gameLocks:    make(map[string]*sync.Mutex),
+	}
+}
+
+// RegisterEngine makes engine available as an AI level under its name,
+// which must not be one of the built-in difficulties. Engines are
+// registered before the server starts accepting players.
+func (s *GameService) RegisterEngine(engine ai.Engine) error {
+	name := engine.Name()
+	if _, builtIn := ai.ParseDifficulty(name); builtIn {
+		return fmt.Errorf("engine name %q is reserved", name)
+	}
+	if _, ok := s.engines[name]; ok {
+		return fmt.Errorf("engine %q is already registered", name)
+	}
+	s.engines[name] = engine
+	slog.Info("engine registered", "engine", name)
+	return nil
+}
+
+// Engine returns the registered engine called name.
+func (s *GameService) Engine(name string) (ai.Engine, bool) {
+	engine, ok := s.engines[name]
+	return engine, ok
+}
+
+// EngineNames lists the registered engines in alphabetical order.
+func (s *GameService) EngineNames() []string {
+	names := make([]string, 0, len(s.engines))
+	for name := range s.engines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseAILevel validates the level named in "join ai": a difficulty,
+// defaulting to medium, or the name of a registered engine.
+func (s *GameService) ParseAILevel(name string) (string, bool) {
+	if _, ok := s.engines[name]; ok {
+		return name, true
+	}
+	difficulty, ok := ai.ParseDifficulty(name)
+	return string(difficulty), ok
+}
+
+// StartAIGame starts a game against the AI playing at level, a difficulty
+// or engine name checked with ParseAILevel, with the player playing symbol,
+// X or O. X moves first, so when the player takes O the AI makes its
+// opening move straight away and the returned line describes it.
+func (s *GameService) StartAIGame(username, level, symbol string) (string, string, error) {
+	players := []string{username, game.AIPlayer}
+	if symbol == "O" {
+		players = []string{game.AIPlayer, username}
+	}
+	gameID := fmt.Sprintf("game-%d", time.Now().UnixNano())
+	g := game.NewGame(gameID, players, true)
+	g.AIDifficulty = level
+	logger := slog.With("game_id", gameID, "username", username)
+	result := ""
+	if g.CurrentTurn == game.AIPlayer {
+		var err error
+		if result, err = s.playAIMove(g, logger); err != nil {
+			return "", "", err
+		}
 	}
+	if err := s.gameRepo.Save(g); err != nil {
+		return "", "", err
+	}
+	logger.Info("AI game started", "difficulty", level, "symbol", g.SymbolOf(username))
+	return gameID, result, nil
 }
 
-func (s *GameService) StartAIGame(username string) (string, error) {
-	aiUsername := "AI"
+// StartExhibition starts a game between two AIs, playing X at levelX and O
+// at levelO, each a difficulty or engine name checked with ParseAILevel.
+// The players are named after their levels so they can be told apart.
+func (s *GameService) StartExhibition(levelX, levelO string) (string, error) {
+	games, err := s.gameRepo.All()
+	if err != nil {
+		return "", err
+	}
+	running := 0
+	for _, g := range games {
+		if g.IsExhibition() && !g.Over() {
+			running++
+		}
+	}
+	if running >= MaxExhibitions {
+		return "", fmt.Errorf("%d exhibition games are already running; watch one of those instead", running)
+	}
+	playerX := fmt.Sprintf("AI %s (X)", levelX)
+	playerO := fmt.Sprintf("AI %s (O)", levelO)
 	gameID := fmt.Sprintf("game-%d", time.Now().UnixNano())
-	g := game.NewGame(gameID, []string{username, aiUsername}, true)
+	g := game.NewGame(gameID, []string{playerX, playerO}, false)
+	g.AILevels = map[string]string{playerX: levelX, playerO: levelO}
 	if err := s.gameRepo.Save(g); err != nil {
 		return "", err
 	}
-	log.Printf("Started AI game: gameID=%s, player=%s", gameID, username)
+	slog.Info("exhibition game started", "game_id", gameID, "x", levelX, "o", levelO)
 	return gameID, nil
 }
 
-func (s *GameService) MakeMove(gameID, username string, position int) (string, string, string, error) {
+// PlayExhibitionMove makes the next move of an exhibition game for the AI
+// whose turn it is. It returns the updated game and a line describing the
+// move, or the outcome once the game is over.
+func (s *GameService) PlayExhibitionMove(gameID string) (*game.Game, string, error) {
+	defer s.lockGame(gameID)()
+	logger := slog.With("game_id", gameID)
+	g, err := s.gameRepo.FindByID(gameID)
+	if err != nil {
+		return nil, "", err
+	}
+	if !g.IsExhibition() {
+		return nil, "", errors.New("not an exhibition game")
+	}
+	player := g.CurrentTurn
+	level := g.AILevels[player]
+	started := time.Now()
+	position, note := s.aiMove(g, player, level)
+	metrics.AIMoveDuration.Observe(time.Since(started).Seconds(), level)
+	if position == -1 {
+		logger.Error("AI found no move", "difficulty", level)
+		return nil, "", errors.New("AI failed to make a move")
+	}
+	if err := g.MakeMove(logger, player, position); err != nil {
+		logger.Error("AI move rejected", "position", position, "difficulty", level, "error", err)
+		return nil, "", err
+	}
+	metrics.MovesTotal.Inc(gameMode(g))
+	result := note + fmt.Sprintf("%s chooses position %d", player, position+1)
+	if g.Winner != "" {
+		result += fmt.Sprintf("\n%s wins!", g.Winner)
+	} else if g.IsDraw {
+		result += "\nIt's a draw!"
+	}
+	if err := s.gameRepo.Save(g); err != nil {
+		logger.Error("failed to save game", "error", err)
+		return nil, "", err
+	}
+	return g, result, nil
+}
+
+// MakeMove plays username's move and, in AI games, the AI's reply. It
+// returns the updated game, a line describing the outcome and any bonus
+// message earned by the move. logger is the player's Logger, so every entry
+// about the move, down to the domain, carries the client's remote address.
+func (s *GameService) MakeMove(logger *slog.Logger, gameID, username string, position int) (*game.Game, string, string, error) {
+	defer s.lockGame(gameID)()
 	g, err := s.gameRepo.FindByID(gameID)
 	if err != nil {
-		log.Printf("MakeMove: gameID=%s not found", gameID)
-		return "", "", "", err
+		logger.Warn("move for unknown game", "position", position)
+		return nil, "", "", err
 	}
-	log.Printf("MakeMove: gameID=%s, username=%s, position=%d, board=%v", gameID, username, position, g.Board)
-	if err := g.MakeMove(username, position); err != nil {
-		log.Printf("MakeMove: invalid move for gameID=%s, username=%s: %v", gameID, username, err)
-		return "", "", "", err
+	if err := g.MakeMove(logger, username, position); err != nil {
+		logger.Info("invalid move", "position", position, "error", err)
+		return nil, "", "", err
 	}
+	metrics.MovesTotal.Inc(gameMode(g))
 	result := ""
+	if !g.Over() && g.IsAIGame && g.CurrentTurn == game.AIPlayer {
+		if result, err = s.playAIMove(g, logger); err != nil {
+			return nil, "", "", err
+		}
+	}
 	bonusMsg := ""
-	if g.Winner != "" {
-		winner, err := s.userRepo.FindByUsername(g.Winner)
+	if g.Over() {
+		if g.Winner != "" {
+			result = fmt.Sprintf("%s wins!", g.Winner)
+		} else {
+			result = "It's a draw!"
+		}
+		bonusMsg, err = s.recordResults(g)
 		if err != nil {
-			log.Printf("MakeMove: winner %s not found", g.Winner)
-			return "", "", "", err
-		}
-		isAIGame := g.IsAIGame && g.Winner != "AI"
-		bonusMsg = winner.WinGame(isAIGame)
-		result = fmt.Sprintf("%s wins!", g.Winner)
-		if !g.IsAIGame {
-			loserUsername := g.Players[0]
-			if loserUsername == g.Winner {
-				loserUsername = g.Players[1]
-			}
-			loser, err := s.userRepo.FindByUsername(loserUsername)
-			if err != nil {
-				log.Printf("MakeMove: loser %s not found", loserUsername)
-				return "", "", "", err
-			}
-			loser.LoseGame()
-			s.userRepo.Save(loser)
+			return nil, "", "", err
 		}
-	} else if g.IsDraw {
-		result = "It's a draw!"
+		s.learn(g)
+	}
+	if err := s.gameRepo.Save(g); err != nil {
+		logger.Error("failed to save game", "error", err)
+		return nil, "", "", err
+	}
+	logger.Debug("move played", "position", position, "result", result, "bonus", bonusMsg)
+	return g, result, bonusMsg, nil
+}
+
+// playAIMove makes the AI's move in an AI game, logging to the logger of
+// the request that led to it, and returns the line describing it.
+func (s *GameService) playAIMove(g *game.Game, logger *slog.Logger) (string, error) {
+	started := time.Now()
+	position, note := s.aiMove(g, game.AIPlayer, g.AIDifficulty)
+	metrics.AIMoveDuration.Observe(time.Since(started).Seconds(), g.AIDifficulty)
+	if position == -1 {
+		logger.Error("AI found no move", "difficulty", g.AIDifficulty)
+		return "", errors.New("AI failed to make a move")
+	}
+	if err := g.MakeMove(logger, game.AIPlayer, position); err != nil {
+		logger.Error("AI move rejected", "position", position, "error", err)
+		return "", err
+	}
+	metrics.MovesTotal.Inc(gameMode(g))
+	return note + fmt.Sprintf("AI chooses position %d", position+1), nil
+}
+
+// aiMove picks player's move at level, a difficulty or engine name. When
+// an engine fails, the medium AI moves instead, the game is marked
+// EngineFailed and the returned note says so.
+func (s *GameService) aiMove(g *game.Game, player, level string) (int, string) {
+	engine, ok := s.engines[level]
+	if !ok {
+		return ai.Move(g, player, ai.Difficulty(level)), ""
+	}
+	position, err := engine.Move(g, player)
+	if err != nil {
+		g.EngineFailed = true
+		return ai.Move(g, player, ai.Medium), fmt.Sprintf("Engine %s failed, so the built-in AI moved for it.\n", engine.Name())
+	}
+	return position, ""
+}
+
+// learn lets the engine that played a finished AI game learn from it, if
+// it is a Learner. Games where the built-in AI moved for the engine are
+// skipped, since the engine would take those moves for its own. A failure
+// to save what it learned is logged rather than spoiling the player's game.
+func (s *GameService) learn(g *game.Game) {
+	if !g.IsAIGame {
+		return
+	}
+	learner, ok := s.engines[g.AIDifficulty].(ai.Learner)
+	if !ok {
+		return
+	}
+	if g.EngineFailed {
+		slog.Info("engine not learning from game it failed in", "game_id", g.ID, "engine", learner.Name())
+		return
+	}
+	if err := learner.Learn(g, game.AIPlayer); err != nil {
+		slog.Error("engine failed to learn", "game_id", g.ID, "engine", learner.Name(), "error", err)
+	}
+}
+
+// Hint returns the best move for username in their AI game, found by
+// solving the position. Hints are not available in two-player games, which
+// are rated, and asking for one marks the AI game as assisted so a win or
+// draw earns no points. first reports whether this is the first hint in the
+// game.
+func (s *GameService) Hint(gameID, username string) (move ai.MoveValue, first bool, err error) {
+	g, first, err := s.assist(gameID, username)
+	if err != nil {
+		return ai.MoveValue{}, false, err
+	}
+	return ai.Solve(g), first, nil
+}
+
+// Analyze is Hint for every empty cell, best first.
+func (s *GameService) Analyze(gameID, username string) (values []ai.MoveValue, first bool, err error) {
+	g, first, err := s.assist(gameID, username)
+	if err != nil {
+		return nil, false, err
+	}
+	return ai.Analyze(g), first, nil
+}
+
+// assist checks that username may get help in the game and marks it as
+// assisted, reporting whether it already was.
+func (s *GameService) assist(gameID, username string) (*game.Game, bool, error) {
+	defer s.lockGame(gameID)()
+	g, err := s.gameRepo.FindByID(gameID)
+	if err != nil {
+		return nil, false, err
+	}
+	switch {
+	case !g.IsAIGame:
+		return nil, false, errors.New("hints are not available in rated two-player games")
+	case g.Over():
+		return nil, false, game.ErrGameOver
+	case g.CurrentTurn != username:
+		return nil, false, game.ErrNotYourTurn
+	}
+	first := !g.Assisted
+	if first {
+		g.Assisted = true
+		if err := s.gameRepo.Save(g); err != nil {
+			return nil, false, err
+		}
+		slog.Info("AI game assisted", "game_id", gameID, "username", username)
+	}
+	return g, first, nil
+}
+
+// recordResults updates the stats of every human player in a finished game,
+// appends their entries to the results log and returns the bonus message
+// earned by the winner, if any. Games a bot took part in change Elo
+// ratings instead of scores, and the message reports the new ratings.
+func (s *GameService) recordResults(g *game.Game) (string, error) {
+	users := make(map[string]*user.User)
+	botGame := false
+	for _, player := range g.Players {
+		if player == game.AIPlayer {
+			continue
+		}
+		u, err := s.userRepo.FindByUsername(player)
+		if err != nil {
+			slog.Error("player of finished game not found", "game_id", g.ID, "username", player)
+			return "", err
+		}
+		users[player] = u
+		botGame = botGame || u.IsBot
+	}
+	mode := result.Mode(gameMode(g))
+	ratings := make(map[string]int)
+	if botGame {
+		mode = result.ModeBot
 		for _, player := range g.Players {
-			if player != "AI" {
-				u, err := s.userRepo.FindByUsername(player)
-				if err != nil {
-					log.Printf("MakeMove: player %s not found", player)
-					return "", "", "", err
+			if u, ok := users[player]; ok {
+				ratings[player] = u.BotRating
+				if u.BotRating == 0 {
+					ratings[player] = scoring.InitialRating
 				}
-				u.DrawGame()
-				s.userRepo.Save(u)
+			} else {
+				ratings[player] = s.rules.AIRating(g.AIDifficulty)
 			}
 		}
-	} else if g.IsAIGame {
-		aiMove := ai.AIMove(g, "AI")
-		if aiMove == -1 {
-			log.Printf("MakeMove: AI failed to make a move for gameID=%s", gameID)
-			return "", "", "", errors.New("AI failed to make a move")
-		}
-		if err := g.MakeMove("AI", aiMove); err != nil {
-			log.Printf("MakeMove: AI move failed for gameID=%s: %v", gameID, err)
-			return "", "", "", err
-		}
-		result = fmt.Sprintf("AI chooses position %d", aiMove+1)
-		if g.Winner == "AI" {
-			result = "AI wins!"
-			u, err := s.userRepo.FindByUsername(username)
-			if err != nil {
-				log.Printf("MakeMove: player %s not found", username)
-				return "", "", "", err
-			}
-			u.LoseGame()
-			s.userRepo.Save(u)
+	}
+
+	bonusMsg := ""
+	var ratingChanges []string
+	finishedAt := time.Now()
+	for _, player := range g.Players {
+		u, ok := users[player]
+		if !ok {
+			continue
+		}
+		r := user.GameResult{
+			Opponent: g.Opponent(player),
+			IsAIGame: g.IsAIGame,
+			BotGame:  botGame,
+			Assisted: g.Assisted,
+			Symbol:   g.SymbolOf(player),
+			Moves:    g.MoveCount(),
+		}
+		outcome := result.Loss
+		if g.Winner == player {
+			u.WinGame(r)
+			outcome = result.Win
 		} else if g.IsDraw {
-			result = "It's a draw!"
-			u, err := s.userRepo.FindByUsername(username)
-			if err != nil {
-				log.Printf("MakeMove: player %s not found", username)
-				return "", "", "", err
+			u.DrawGame(r)
+			outcome = result.Draw
+		} else {
+			u.LoseGame(r)
+		}
+		entry := audit.Entry{
+			Event:    audit.EventScoreChange,
+			Username: player,
+			GameID:   g.ID,
+			Outcome:  string(outcome),
+		}
+		points := 0
+		if g.Assisted && outcome != result.Loss {
+			score := u.Score
+			entry.Points = &points
+			entry.Score = &score
+			entry.Message = "assisted"
+			bonusMsg = "Hints were used, so a win or draw earns no points."
+		} else if botGame {
+			change := s.rules.RatingChange(ratings[player], ratings[g.Opponent(player)], outcome)
+			u.BotRating = ratings[player] + change
+			rating := u.BotRating
+			entry.Rating = &rating
+			ratingChanges = append(ratingChanges, fmt.Sprintf("%s %d (%+d)", player, u.BotRating, change))
+		} else {
+			var bonus string
+			points, bonus = s.rules.Award(u.Score, outcome, mode, g.AIDifficulty, u.WinStreak)
+			u.AddPoints(points)
+			if bonus != "" {
+				bonusMsg = bonus
 			}
-			u.DrawGame()
-			s.userRepo.Save(u)
+			score := u.Score
+			entry.Points = &points
+			entry.Score = &score
+			entry.Message = bonus
+		}
+		s.userRepo.Save(u)
+		s.auditLog.Record(entry)
+		s.resultRepo.Save(&result.Result{
+			GameID:     g.ID,
+			Username:   player,
+			Mode:       mode,
+			Outcome:    outcome,
+			Points:     points,
+			FinishedAt: finishedAt,
+		})
+		if !g.Assisted {
+			s.achievements.Evaluate(u, g)
 		}
 	}
-	if err := s.gameRepo.Save(g); err != nil {
-		log.Printf("MakeMove: failed to save gameID=%s: %v", gameID, err)
-		return "", "", "", err
+	if len(ratingChanges) > 0 {
+		bonusMsg = "Bot ratings: " + strings.Join(ratingChanges, ", ")
 	}
-	log.Printf("MakeMove: gameID=%s, result=%s, bonusMsg=%s", gameID, result, bonusMsg)
-	return g.DisplayString(), result, bonusMsg, nil
+	return bonusMsg, nil
 }
 
-func (s *GameService) GetBoard(gameID string) string {
+// ActiveGamesByMode counts the games in progress keyed by mode.
+func (s *GameService) ActiveGamesByMode() map[string]float64 {
+	counts := map[string]float64{
+		string(result.ModeAI):        0,
+		string(result.ModeTwoPlayer): 0,
+		ModeExhibition:               0,
+	}
+	games, err := s.gameRepo.All()
+	if err != nil {
+		return counts
+	}
+	for _, g := range games {
+		if !g.Over() {
+			counts[gameMode(g)]++
+		}
+	}
+	return counts
+}
+
+func gameMode(g *game.Game) string {
+	if g.IsExhibition() {
+		return ModeExhibition
+	}
+	if g.IsAIGame {
+		return string(result.ModeAI)
+	}
+	return string(result.ModeTwoPlayer)
+}
+
+// TakeAnnouncements returns and clears the achievement unlock messages
+// waiting for username.
+func (s *GameService) TakeAnnouncements(username string) []string {
+	return s.achievements.TakeAnnouncements(username)
+}
+
+// Turn returns the player due to move in the game and when their turn
+// started, or "" once the game is over.
+func (s *GameService) Turn(gameID string) (string, time.Time, error) {
 	g, err := s.gameRepo.FindByID(gameID)
 	if err != nil {
-		return "Game not found"
+		return "", time.Time{}, err
+	}
+	if g.Over() {
+		return "", time.Time{}, nil
 	}
-	return g.DisplayString()
+	return g.CurrentTurn, g.TurnStartedAt, nil
 }
 
 func (s *GameService) GetCurrentTurn(gameID string) string {
@@ -145,10 +519,71 @@ func (s *GameService) IsAIGame(gameID string) bool {
 	return g.IsAIGame
 }
 
+// ActiveGames returns the games in progress, oldest first.
+func (s *GameService) ActiveGames() []*game.Game {
+	games, err := s.gameRepo.All()
+	if err != nil {
+		return nil
+	}
+	var active []*game.Game
+	for _, g := range games {
+		if !g.Over() {
+			active = append(active, g)
+		}
+	}
+	sort.Slice(active, func(i, j int) bool { return active[i].ID < active[j].ID })
+	return active
+}
+
 func (s *GameService) FindGameByID(gameID string) (*game.Game, error) {
 	return s.gameRepo.FindByID(gameID)
 }
 
+// DeleteGame removes a finished or abandoned game, keeping it as the last
+// game of each of its human players for Review.
 func (s *GameService) DeleteGame(gameID string) error {
+	if g, err := s.gameRepo.FindByID(gameID); err == nil && len(g.Moves) > 0 && !g.IsExhibition() {
+		s.mu.Lock()
+		for _, player := range g.Players {
+			if player != game.AIPlayer {
+				s.lastGames[player] = g
+			}
+		}
+		s.mu.Unlock()
+	}
+	s.mu.Lock()
+	delete(s.gameLocks, gameID)
+	s.mu.Unlock()
 	return s.gameRepo.Delete(gameID)
 }
+
+// lockGame locks the game for a change and returns the function that
+// unlocks it.
+func (s *GameService) lockGame(gameID string) func() {
+	s.mu.Lock()
+	lock, ok := s.gameLocks[gameID]
+	if !ok {
+		lock = new(sync.Mutex)
+		s.gameLocks[gameID] = lock
+	}
+	s.mu.Unlock()
+	lock.Lock()
+	return lock.Unlock
+}
+
+// Review grades every move of username's last game against perfect play.
+// Only games that are no longer in play are kept, so a review cannot be
+// used as a hint.
+func (s *GameService) Review(username string) (*game.Game, []ai.MoveReview, error) {
+	s.mu.Lock()
+	g, ok := s.lastGames[username]
+	s.mu.Unlock()
+	if !ok {
+		return nil, nil, errors.New("no finished game to review")
+	}
+	reviews, err := ai.Review(g)
+	if err != nil {
+		return nil, nil, err
+	}
+	return g, reviews, nil
+}
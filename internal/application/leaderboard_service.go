@@ -1,30 +1,395 @@
 package application
 
 import (
+	"errors"
 	"fmt"
 	"sort"
+	"strings"
+	"sync"
+	"tic-tac-toe/internal/domain/result"
+	"tic-tac-toe/internal/domain/season"
 	"tic-tac-toe/internal/domain/user"
+	"time"
 )
 
 type LeaderboardService struct {
-	userRepo user.UserRepository
+	userRepo     user.UserRepository
+	resultRepo   result.ResultRepository
+	seasonRepo   season.SeasonRepository
+	achievements *AchievementService
+	seasonStart  time.Time
+	mu           sync.Mutex // guards seasonStart
 }
 
-func NewLeaderboardService(userRepo user.UserRepository) *LeaderboardService {
-	return &LeaderboardService{userRepo: userRepo}
+func NewLeaderboardService(userRepo user.UserRepository, resultRepo result.ResultRepository, seasonRepo season.SeasonRepository, achievements *AchievementService) *LeaderboardService {
+	s := &LeaderboardService{
+		userRepo:     userRepo,
+		resultRepo:   resultRepo,
+		seasonRepo:   seasonRepo,
+		achievements: achievements,
+		seasonStart:  time.Now(),
+	}
+	if seasons, err := seasonRepo.All(); err == nil && len(seasons) > 0 {
+		s.seasonStart = seasons[len(seasons)-1].EndedAt
+	}
+	return s
 }
 
-func (s *LeaderboardService) GetLeaderboard() (string, error) {
-	users, err := s.userRepo.All()
+// Window selects how far back the results log is read.
+type Window string
+
+const (
+	WindowDaily   Window = "daily"
+	WindowWeekly  Window = "weekly"
+	WindowMonthly Window = "monthly"
+	WindowAll     Window = "all"
+)
+
+// LeaderboardQuery filters and limits a leaderboard. An empty Mode includes
+// every mode and a zero Top lists every player.
+type LeaderboardQuery struct {
+	Window Window
+	Mode   result.Mode
+	Top    int
+}
+
+// Standing is one row of a leaderboard. Rating and Bot are only set on the
+// bot leaderboard.
+type Standing struct {
+	Username  string
+	Points    int
+	Wins      int
+	WinStreak int
+	Rating    int
+	Bot       bool
+}
+
+func (s *LeaderboardService) GetLeaderboard(q LeaderboardQuery) (string, error) {
+	standings, err := s.GetStandings(q)
 	if err != nil {
 		return "", err
 	}
-	sort.Slice(users, func(i, j int) bool {
-		return users[i].Score > users[j].Score
+	leaderboard := "Leaderboard" + describeQuery(q) + ":\n"
+	for i, st := range standings {
+		leaderboard += formatStanding(i+1, st, q)
+	}
+	return leaderboard, nil
+}
+
+// GetStandings returns the rows of the leaderboard selected by q, best
+// first.
+func (s *LeaderboardService) GetStandings(q LeaderboardQuery) ([]Standing, error) {
+	standings, err := s.standings(q)
+	if err != nil {
+		return nil, err
+	}
+	if q.Top > 0 && len(standings) > q.Top {
+		standings = standings[:q.Top]
+	}
+	return standings, nil
+}
+
+// GetRank shows username's position together with the players directly
+// above and below them.
+func (s *LeaderboardService) GetRank(username string, q LeaderboardQuery) (string, error) {
+	standings, err := s.standings(q)
+	if err != nil {
+		return "", err
+	}
+	for i, st := range standings {
+		if st.Username != username {
+			continue
+		}
+		rank := fmt.Sprintf("Your rank%s: %d of %d\n", describeQuery(q), i+1, len(standings))
+		for j := max(i-1, 0); j <= min(i+1, len(standings)-1); j++ {
+			rank += formatStanding(j+1, standings[j], q)
+		}
+		return rank, nil
+	}
+	return "", errors.New("no ranked games in this leaderboard")
+}
+
+// standings ranks players by points in the current season. The leaderboard
+// across every mode and the whole season uses the live scores on each user;
+// any other query is computed from the results log. Bots and bot games are
+// left out; they are ranked by GetBotStandings.
+func (s *LeaderboardService) standings(q LeaderboardQuery) ([]Standing, error) {
+	var standings []Standing
+	if q.Window == WindowAll && q.Mode == "" {
+		users, err := s.userRepo.All()
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range users {
+			if u.IsBot {
+				continue
+			}
+			standings = append(standings, Standing{
+				Username:  u.Username,
+				Points:    u.Score,
+				Wins:      u.AIRecord.Wins + u.TwoPlayerRecord.Wins,
+				WinStreak: u.WinStreak,
+			})
+		}
+	} else {
+		since := q.Window.Since(time.Now())
+		if start := s.currentSeasonStart(); since.Before(start) {
+			since = start
+		}
+		results, err := s.resultRepo.Since(since)
+		if err != nil {
+			return nil, err
+		}
+		byUser := make(map[string]*Standing)
+		for _, r := range results {
+			if r.Mode == result.ModeBot || (q.Mode != "" && r.Mode != q.Mode) {
+				continue
+			}
+			st, ok := byUser[r.Username]
+			if !ok {
+				st = &Standing{Username: r.Username}
+				byUser[r.Username] = st
+			}
+			st.Points += r.Points
+			if r.Outcome == result.Win {
+				st.Wins++
+			}
+		}
+		for _, st := range byUser {
+			standings = append(standings, *st)
+		}
+	}
+	sort.Slice(standings, func(i, j int) bool {
+		if standings[i].Points != standings[j].Points {
+			return standings[i].Points > standings[j].Points
+		}
+		return standings[i].Username < standings[j].Username
 	})
-	leaderboard := "Leaderboard:\n"
+	return standings, nil
+}
+
+// GetBotStandings ranks everyone who has played a game involving a bot by
+// their bot rating, best first, limited to the top players if top > 0.
+func (s *LeaderboardService) GetBotStandings(top int) ([]Standing, error) {
+	users, err := s.userRepo.All()
+	if err != nil {
+		return nil, err
+	}
+	var standings []Standing
 	for _, u := range users {
-		leaderboard += fmt.Sprintf("%s: %d points, %d win streak\n", u.Username, u.Score, u.WinStreak)
+		if u.BotRecord.Games() == 0 {
+			continue
+		}
+		standings = append(standings, Standing{
+			Username: u.Username,
+			Wins:     u.BotRecord.Wins,
+			Rating:   u.BotRating,
+			Bot:      u.IsBot,
+		})
+	}
+	sort.Slice(standings, func(i, j int) bool {
+		if standings[i].Rating != standings[j].Rating {
+			return standings[i].Rating > standings[j].Rating
+		}
+		return standings[i].Username < standings[j].Username
+	})
+	if top > 0 && len(standings) > top {
+		standings = standings[:top]
+	}
+	return standings, nil
+}
+
+// GetBotLeaderboard formats GetBotStandings.
+func (s *LeaderboardService) GetBotLeaderboard(top int) (string, error) {
+	standings, err := s.GetBotStandings(top)
+	if err != nil {
+		return "", err
+	}
+	if len(standings) == 0 {
+		return "No bot games have been played yet.", nil
+	}
+	leaderboard := "Bot leaderboard:\n"
+	for i, st := range standings {
+		kind := ""
+		if st.Bot {
+			kind = " [bot]"
+		}
+		leaderboard += fmt.Sprintf("%d. %s%s: rating %d, %d wins\n", i+1, st.Username, kind, st.Rating, st.Wins)
 	}
 	return leaderboard, nil
 }
+
+// StartSeason archives the final standings of the current season, awards
+// the champion badge and resets every user's score and win streak.
+func (s *LeaderboardService) StartSeason() (*season.Season, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	seasons, err := s.seasonRepo.All()
+	if err != nil {
+		return nil, err
+	}
+	standings, err := s.standings(LeaderboardQuery{Window: WindowAll})
+	if err != nil {
+		return nil, err
+	}
+	archived := &season.Season{
+		Number:    len(seasons) + 1,
+		StartedAt: s.seasonStart,
+		EndedAt:   time.Now(),
+	}
+	for _, st := range standings {
+		archived.Standings = append(archived.Standings, season.Standing{
+			Username:  st.Username,
+			Score:     st.Points,
+			WinStreak: st.WinStreak,
+		})
+	}
+	if err := s.seasonRepo.Save(archived); err != nil {
+		return nil, err
+	}
+	users, err := s.userRepo.All()
+	if err != nil {
+		return nil, err
+	}
+	champion := archived.Champion()
+	for _, u := range users {
+		if u.Username == champion {
+			u.ChampionSeasons = append(u.ChampionSeasons, archived.Number)
+			s.achievements.Evaluate(u, nil)
+		}
+		u.Score = 0
+		u.WinStreak = 0
+		s.userRepo.Save(u)
+	}
+	s.seasonStart = archived.EndedAt
+	return archived, nil
+}
+
+// ResetScore clears a user's score and win streak for the current season.
+func (s *LeaderboardService) ResetScore(username string) error {
+	u, err := s.userRepo.FindByUsername(username)
+	if err != nil {
+		return err
+	}
+	u.Score = 0
+	u.WinStreak = 0
+	return s.userRepo.Save(u)
+}
+
+// GetSeason formats the final standings of an archived season.
+func (s *LeaderboardService) GetSeason(number int) (string, error) {
+	archived, err := s.seasonRepo.FindByNumber(number)
+	if err != nil {
+		return "", err
+	}
+	standings := fmt.Sprintf("Season %d (%s - %s):\n", archived.Number,
+		archived.StartedAt.Format(time.DateOnly), archived.EndedAt.Format(time.DateOnly))
+	for i, st := range archived.Standings {
+		standings += fmt.Sprintf("%d. %s: %d points, %d win streak\n", i+1, st.Username, st.Score, st.WinStreak)
+	}
+	return standings, nil
+}
+
+// GetSeasons lists every archived season and its champion.
+func (s *LeaderboardService) GetSeasons() (string, error) {
+	seasons, err := s.seasonRepo.All()
+	if err != nil {
+		return "", err
+	}
+	if len(seasons) == 0 {
+		return "No seasons have finished yet.", nil
+	}
+	list := "Seasons:\n"
+	for _, archived := range seasons {
+		champion := archived.Champion()
+		if champion == "" {
+			champion = "none"
+		}
+		list += fmt.Sprintf("Season %d: champion %s\n", archived.Number, champion)
+	}
+	return list, nil
+}
+
+func (s *LeaderboardService) currentSeasonStart() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.seasonStart
+}
+
+// Since returns the start of the window relative to now.
+func (w Window) Since(now time.Time) time.Time {
+	switch w {
+	case WindowDaily:
+		return now.AddDate(0, 0, -1)
+	case WindowWeekly:
+		return now.AddDate(0, 0, -7)
+	case WindowMonthly:
+		return now.AddDate(0, -1, 0)
+	}
+	return time.Time{}
+}
+
+func describeQuery(q LeaderboardQuery) string {
+	var filters []string
+	if q.Window != WindowAll {
+		filters = append(filters, string(q.Window))
+	}
+	if q.Mode != "" {
+		filters = append(filters, string(q.Mode))
+	}
+	if len(filters) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(filters, ", ") + ")"
+}
+
+func formatStanding(rank int, st Standing, q LeaderboardQuery) string {
+	if q.Window == WindowAll && q.Mode == "" {
+		return fmt.Sprintf("%d. %s: %d points, %d win streak\n", rank, st.Username, st.Points, st.WinStreak)
+	}
+	return fmt.Sprintf("%d. %s: %d points, %d wins\n", rank, st.Username, st.Points, st.Wins)
+}
+
+// GetProfile formats the detailed statistics of a single user.
+func (s *LeaderboardService) GetProfile(username string) (string, error) {
+	u, err := s.userRepo.FindByUsername(username)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Profile: %s\n", u.Username)
+	if u.IsBot {
+		sb.WriteString("[Bot]\n")
+	}
+	for _, number := range u.ChampionSeasons {
+		fmt.Fprintf(&sb, "[Season %d Champion]\n", number)
+	}
+	fmt.Fprintf(&sb, "Score: %d points, %d win streak (longest %d)\n", u.Score, u.WinStreak, u.LongestStreak)
+	fmt.Fprintf(&sb, "Games played: %d (%d as X, %d as O), average length %.1f moves\n", u.GamesPlayed(), u.GamesAsX, u.GamesAsO, u.AverageGameLength())
+	fmt.Fprintf(&sb, "vs AI: %s\n", formatRecord(u.AIRecord))
+	fmt.Fprintf(&sb, "Two-player: %s\n", formatRecord(u.TwoPlayerRecord))
+	if u.BotRecord.Games() > 0 {
+		fmt.Fprintf(&sb, "Bot games: %s, rating %d\n", formatRecord(u.BotRecord), u.BotRating)
+	}
+	if len(u.HeadToHead) > 0 {
+		opponents := make([]string, 0, len(u.HeadToHead))
+		for opponent := range u.HeadToHead {
+			opponents = append(opponents, opponent)
+		}
+		sort.Strings(opponents)
+		sb.WriteString("Head-to-head:\n")
+		for _, opponent := range opponents {
+			fmt.Fprintf(&sb, "  vs %s: %s\n", opponent, formatRecord(*u.HeadToHead[opponent]))
+		}
+	}
+	return sb.String(), nil
+}
+
+// GetAchievements lists every achievement and whether username has it.
+func (s *LeaderboardService) GetAchievements(username string) (string, error) {
+	return s.achievements.GetAchievements(username)
+}
+
+func formatRecord(r user.Record) string {
+	return fmt.Sprintf("%d wins, %d losses, %d draws", r.Wins, r.Losses, r.Draws)
+}
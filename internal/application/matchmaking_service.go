@@ -1,23 +1,31 @@
 package application
 
 import (
+	"errors"
 	"fmt"
 	"sync"
 	"tic-tac-toe/internal/domain/game"
+	"tic-tac-toe/internal/metrics"
 	"time"
 )
 
-// MatchmakingService manages pairing players for two-player games.
+// MatchmakingService manages pairing players for two-player games, from
+// the waiting queue or by challenging a player directly.
 type MatchmakingService struct {
 	gameRepo game.GameRepository
 	waiting  []string
-	mu       sync.Mutex
+	joinedAt map[string]time.Time
+	// challenges holds the pending challengers of each challenged player.
+	challenges map[string]map[string]bool
+	mu         sync.Mutex
 }
 
 func NewMatchmakingService(gameRepo game.GameRepository) *MatchmakingService {
 	return &MatchmakingService{
-		gameRepo: gameRepo,
-		waiting:  make([]string, 0),
+		gameRepo:   gameRepo,
+		waiting:    make([]string, 0),
+		joinedAt:   make(map[string]time.Time),
+		challenges: make(map[string]map[string]bool),
 	}
 }
 
@@ -31,6 +39,10 @@ func (s *MatchmakingService) JoinTwoPlayerGame(username string) (string, error)
 			return s.JoinTwoPlayerGame(username) // Retry
 		}
 		s.waiting = s.waiting[1:]
+		if joinedAt, ok := s.joinedAt[opponent]; ok {
+			metrics.MatchmakingWait.Observe(time.Since(joinedAt).Seconds())
+			delete(s.joinedAt, opponent)
+		}
 		s.mu.Unlock()
 
 		gameID := fmt.Sprintf("game-%d", time.Now().UnixNano())
@@ -41,6 +53,7 @@ func (s *MatchmakingService) JoinTwoPlayerGame(username string) (string, error)
 		return gameID, nil
 	} else {
 		s.waiting = append(s.waiting, username)
+		s.joinedAt[username] = time.Now()
 		s.mu.Unlock()
 		return "", nil // Waiting for opponent
 	}
@@ -56,6 +69,7 @@ func (s *MatchmakingService) AddToWaiting(username string) {
 		}
 	}
 	s.waiting = append(s.waiting, username)
+	s.joinedAt[username] = time.Now()
 }
 
 func (s *MatchmakingService) RemoveFromWaiting(username string) {
@@ -65,7 +79,82 @@ func (s *MatchmakingService) RemoveFromWaiting(username string) {
 	for i, u := range s.waiting {
 		if u == username {
 			s.waiting = append(s.waiting[:i], s.waiting[i+1:]...)
+			delete(s.joinedAt, username)
 			break
 		}
 	}
 }
+
+func (s *MatchmakingService) QueueLength() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.waiting)
+}
+
+// IsWaiting reports whether username is queued for a two-player game.
+func (s *MatchmakingService) IsWaiting(username string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, waiting := range s.waiting {
+		if waiting == username {
+			return true
+		}
+	}
+	return false
+}
+
+// Challenge records that challenger wants to play opponent.
+func (s *MatchmakingService) Challenge(challenger, opponent string) error {
+	if challenger == opponent {
+		return errors.New("you cannot challenge yourself")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.challenges[opponent] == nil {
+		s.challenges[opponent] = make(map[string]bool)
+	}
+	s.challenges[opponent][challenger] = true
+	return nil
+}
+
+// AcceptChallenge starts a game between username and challenger, who moves
+// first, and takes both out of the waiting queue.
+func (s *MatchmakingService) AcceptChallenge(username, challenger string) (string, error) {
+	s.mu.Lock()
+	if !s.challenges[username][challenger] {
+		s.mu.Unlock()
+		return "", errors.New("no challenge from " + challenger)
+	}
+	delete(s.challenges[username], challenger)
+	s.mu.Unlock()
+	s.RemoveFromWaiting(username)
+	s.RemoveFromWaiting(challenger)
+
+	gameID := fmt.Sprintf("game-%d", time.Now().UnixNano())
+	g := game.NewGame(gameID, []string{challenger, username}, false)
+	if err := s.gameRepo.Save(g); err != nil {
+		return "", err
+	}
+	return gameID, nil
+}
+
+// DeclineChallenge drops challenger's challenge to username.
+func (s *MatchmakingService) DeclineChallenge(username, challenger string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.challenges[username][challenger] {
+		return errors.New("no challenge from " + challenger)
+	}
+	delete(s.challenges[username], challenger)
+	return nil
+}
+
+// CancelChallenges drops every challenge made by or to username.
+func (s *MatchmakingService) CancelChallenges(username string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.challenges, username)
+	for _, challengers := range s.challenges {
+		delete(challengers, username)
+	}
+}
@@ -0,0 +1,162 @@
+// Package audit writes an append-only trail of player actions and game
+// outcomes as JSON lines, rotating the file when it grows too large.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	EventLogin       = "login"
+	EventCommand     = "command"
+	EventGameEnd     = "game_end"
+	EventScoreChange = "score_change"
+)
+
+type Entry struct {
+	Time       time.Time `json:"time"`
+	Event      string    `json:"event"`
+	Username   string    `json:"username,omitempty"`
+	GameID     string    `json:"game_id,omitempty"`
+	RemoteAddr string    `json:"remote_addr,omitempty"`
+	Command    string    `json:"command,omitempty"`
+	Args       []string  `json:"args,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Message    string    `json:"message,omitempty"`
+	Outcome    string    `json:"outcome,omitempty"`
+	Points     *int      `json:"points,omitempty"`
+	Score      *int      `json:"score,omitempty"`
+	Rating     *int      `json:"rating,omitempty"`
+}
+
+// Log appends entries to path. Once the file exceeds maxSize bytes it is
+// renamed to path.1 (shifting older files up to path.<maxFiles>) and a new
+// file is started.
+type Log struct {
+	path     string
+	maxSize  int64
+	maxFiles int
+	file     *os.File
+	size     int64
+	mu       sync.Mutex
+}
+
+func Open(path string, maxSize int64, maxFiles int) (*Log, error) {
+	l := &Log{path: path, maxSize: maxSize, maxFiles: maxFiles}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Record appends an entry, stamping it with the current time. Failures are
+// logged rather than returned so auditing never interrupts gameplay.
+func (l *Log) Record(e Entry) {
+	e.Time = time.Now()
+	data, err := json.Marshal(e)
+	if err != nil {
+		slog.Error("failed to encode audit entry", "error", err)
+		return
+	}
+	data = append(data, '\n')
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.size+int64(len(data)) > l.maxSize && l.size > 0 {
+		if err := l.rotate(); err != nil {
+			slog.Error("failed to rotate audit log", "path", l.path, "error", err)
+		}
+	}
+	n, err := l.file.Write(data)
+	l.size += int64(n)
+	if err != nil {
+		slog.Error("failed to write audit entry", "path", l.path, "error", err)
+	}
+}
+
+// Query returns the most recent entries, oldest first, matching username
+// and gameID (an empty filter matches anything), up to limit entries.
+func (l *Log) Query(username, gameID string, limit int) ([]Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var entries []Entry
+	for i := l.maxFiles; i >= 0; i-- {
+		f, err := os.Open(l.rotatedPath(i))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			var e Entry
+			if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+				continue
+			}
+			if (username == "" || e.Username == username) && (gameID == "" || e.GameID == gameID) {
+				entries = append(entries, e)
+			}
+		}
+		err = scanner.Err()
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	return entries, nil
+}
+
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
+
+func (l *Log) open() error {
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	l.file = f
+	l.size = info.Size()
+	return nil
+}
+
+// rotate shifts path.N to path.N+1, dropping the oldest file, and reopens
+// a fresh file at path. The caller must hold l.mu.
+func (l *Log) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+	os.Remove(l.rotatedPath(l.maxFiles))
+	for i := l.maxFiles - 1; i >= 0; i-- {
+		if err := os.Rename(l.rotatedPath(i), l.rotatedPath(i+1)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return l.open()
+}
+
+// rotatedPath returns path for i == 0 and path.i otherwise.
+func (l *Log) rotatedPath(i int) string {
+	if i == 0 {
+		return l.path
+	}
+	return fmt.Sprintf("%s.%d", l.path, i)
+}
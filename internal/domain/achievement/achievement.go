@@ -0,0 +1,89 @@
+package achievement
+
+import (
+	"tic-tac-toe/internal/domain/game"
+	"tic-tac-toe/internal/domain/user"
+)
+
+type Achievement struct {
+	ID          string
+	Name        string
+	Description string
+}
+
+// Context is what a rule inspects. Game is the game that just finished, or
+// nil when rules are evaluated outside a game (e.g. at the end of a season).
+type Context struct {
+	User *user.User
+	Game *game.Game
+}
+
+func (c Context) won() bool {
+	return c.Game != nil && c.Game.Winner == c.User.Username
+}
+
+type Rule struct {
+	Achievement
+	Unlocked func(c Context) bool
+}
+
+var Rules = []Rule{
+	{
+		Achievement: Achievement{ID: "first-win", Name: "First Blood", Description: "Win your first game"},
+		Unlocked:    Context.won,
+	},
+	{
+		Achievement: Achievement{ID: "beat-hard-ai", Name: "Machine Breaker", Description: "Beat the hard AI"},
+		Unlocked: func(c Context) bool {
+			return c.won() && c.Game.IsAIGame && c.Game.AIDifficulty == "hard"
+		},
+	},
+	{
+		Achievement: Achievement{ID: "win-in-3", Name: "Speed Run", Description: "Win a game in 3 moves"},
+		Unlocked: func(c Context) bool {
+			if !c.won() {
+				return false
+			}
+			symbol := c.Game.SymbolOf(c.User.Username)
+			moves := 0
+			for _, cell := range c.Game.Board {
+				if cell == symbol {
+					moves++
+				}
+			}
+			return moves == 3
+		},
+	},
+	{
+		Achievement: Achievement{ID: "ten-draws", Name: "Stalemate", Description: "Draw 10 games"},
+		Unlocked: func(c Context) bool {
+			return c.User.AIRecord.Draws+c.User.TwoPlayerRecord.Draws >= 10
+		},
+	},
+	{
+		Achievement: Achievement{ID: "win-as-o", Name: "Second Mover", Description: "Win a game playing O"},
+		Unlocked: func(c Context) bool {
+			return c.won() && c.Game.SymbolOf(c.User.Username) == "O"
+		},
+	},
+	{
+		Achievement: Achievement{ID: "champion", Name: "Champion", Description: "Finish a season in first place"},
+		Unlocked: func(c Context) bool {
+			return len(c.User.ChampionSeasons) > 0
+		},
+	},
+}
+
+// Evaluate unlocks every achievement whose rule now holds for c.User and
+// returns the ones that were newly unlocked.
+func Evaluate(c Context) []Achievement {
+	var unlocked []Achievement
+	for _, rule := range Rules {
+		if c.User.HasAchievement(rule.ID) || !rule.Unlocked(c) {
+			continue
+		}
+		c.User.UnlockAchievement(rule.ID)
+		unlocked = append(unlocked, rule.Achievement)
+	}
+	return unlocked
+}
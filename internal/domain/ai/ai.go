@@ -3,12 +3,16 @@ package ai
 import (
 	"math/rand"
 	"tic-tac-toe/internal/domain/game"
-	"time"
 )
 
+// AIMove is the medium heuristic: win if possible, otherwise block the
+// opponent's win, otherwise play a random empty cell.
 func AIMove(g *game.Game, aiUsername string) int {
-	aiSymbol := "O"
+	aiSymbol := g.SymbolOf(aiUsername)
 	playerSymbol := "X"
+	if aiSymbol == "X" {
+		playerSymbol = "O"
+	}
 
 	// Try to win
 	if winMove := getWinningMove(g, aiSymbol); winMove != -1 {
@@ -28,22 +32,21 @@ func AIMove(g *game.Game, aiUsername string) int {
 		}
 	}
 	if len(emptyCells) > 0 {
-		rand.Seed(time.Now().UnixNano())
 		return emptyCells[rand.Intn(len(emptyCells))]
 	}
 	return -1 // Should not happen
 }
 
+// getWinningMove returns a cell where symbol completes a line, trying
+// each on a copy of the board, or -1.
 func getWinningMove(g *game.Game, symbol string) int {
-	for i := 0; i < 9; i++ {
-		if g.Board[i] == " " {
-			g.Board[i] = symbol
-			if g.CheckWin(symbol) {
-				g.Board[i] = " " // Undo
-				return i
-			}
-			g.Board[i] = " " // Undo
+	b := BoardOf(g)
+	for _, i := range b.Empty() {
+		b.Cells[i] = symbol[0]
+		if b.WinsAt(i) {
+			return i
 		}
+		b.Cells[i] = 0 // Undo
 	}
 	return -1
 }
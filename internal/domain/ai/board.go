@@ -0,0 +1,95 @@
+package ai
+
+import (
+	"errors"
+	"tic-tac-toe/internal/domain/game"
+)
+
+// Board is a position on a Width by Height grid where InARow marks in a
+// row, column or diagonal win. It generalizes the 3x3 game so that AIs
+// which do not depend on its size, such as MCTS, can play larger boards
+// and variants like four in a row on 6x6. Cells holds 'X', 'O' or 0 for
+// an empty cell, row by row.
+type Board struct {
+	Width, Height, InARow int
+	Cells                 []byte
+}
+
+// NewBoard returns an empty board.
+func NewBoard(width, height, inARow int) Board {
+	return Board{Width: width, Height: height, InARow: inARow, Cells: make([]byte, width*height)}
+}
+
+// BoardOf copies the position of g, so it can be searched without touching
+// the game itself.
+func BoardOf(g *game.Game) Board {
+	b := NewBoard(3, 3, 3)
+	for i, cell := range g.Board {
+		if cell != " " {
+			b.Cells[i] = cell[0]
+		}
+	}
+	return b
+}
+
+// Clone returns a copy of b that can be played on independently.
+func (b Board) Clone() Board {
+	b.Cells = append([]byte(nil), b.Cells...)
+	return b
+}
+
+// Empty lists the empty cells.
+func (b Board) Empty() []int {
+	var empty []int
+	for i, cell := range b.Cells {
+		if cell == 0 {
+			empty = append(empty, i)
+		}
+	}
+	return empty
+}
+
+// Play places symbol at position, returning an error if the cell is off
+// the board or taken.
+func (b Board) Play(position int, symbol byte) error {
+	if position < 0 || position >= len(b.Cells) {
+		return errors.New("invalid position")
+	}
+	if b.Cells[position] != 0 {
+		return errors.New("cell already taken")
+	}
+	b.Cells[position] = symbol
+	return nil
+}
+
+// WinsAt reports whether the mark at position is part of InARow equal
+// marks in a line. Checking only the last move is enough to find a winner.
+func (b Board) WinsAt(position int) bool {
+	symbol := b.Cells[position]
+	if symbol == 0 {
+		return false
+	}
+	row, col := position/b.Width, position%b.Width
+	for _, dir := range [4][2]int{{0, 1}, {1, 0}, {1, 1}, {1, -1}} {
+		count := 1
+		for _, sign := range [2]int{1, -1} {
+			r, c := row+sign*dir[0], col+sign*dir[1]
+			for r >= 0 && r < b.Height && c >= 0 && c < b.Width && b.Cells[r*b.Width+c] == symbol {
+				count++
+				r, c = r+sign*dir[0], c+sign*dir[1]
+			}
+		}
+		if count >= b.InARow {
+			return true
+		}
+	}
+	return false
+}
+
+// other returns the opponent's mark.
+func other(symbol byte) byte {
+	if symbol == 'X' {
+		return 'O'
+	}
+	return 'X'
+}
@@ -0,0 +1,92 @@
+package ai
+
+import (
+	"math/rand"
+	"tic-tac-toe/internal/domain/game"
+)
+
+type Difficulty string
+
+const (
+	Easy   Difficulty = "easy"
+	Medium Difficulty = "medium"
+	Hard   Difficulty = "hard"
+)
+
+// ParseDifficulty validates a difficulty name, defaulting to Medium when
+// name is empty.
+func ParseDifficulty(name string) (Difficulty, bool) {
+	switch Difficulty(name) {
+	case "":
+		return Medium, true
+	case Easy, Medium, Hard:
+		return Difficulty(name), true
+	}
+	return "", false
+}
+
+// Move picks the AI's next position for the given difficulty: Easy plays a
+// random empty cell, Medium uses the AIMove heuristic and Hard plays
+// perfectly.
+func Move(g *game.Game, aiUsername string, difficulty Difficulty) int {
+	switch difficulty {
+	case Easy:
+		return randomMove(g)
+	case Hard:
+		return bestMove(g, aiUsername)
+	}
+	return AIMove(g, aiUsername)
+}
+
+func randomMove(g *game.Game) int {
+	emptyCells := []int{}
+	for i, cell := range g.Board {
+		if cell == " " {
+			emptyCells = append(emptyCells, i)
+		}
+	}
+	if len(emptyCells) == 0 {
+		return -1
+	}
+	return emptyCells[rand.Intn(len(emptyCells))]
+}
+
+// bestMove searches the full game tree with minimax and returns the
+// position with the best outcome for aiUsername, preferring faster wins.
+// It searches a copy of the board, so g can be shown while it thinks.
+func bestMove(g *game.Game, aiUsername string) int {
+	b := BoardOf(g)
+	aiSymbol := g.SymbolOf(aiUsername)[0]
+	best, bestScore := -1, -100
+	for _, i := range b.Empty() {
+		b.Cells[i] = aiSymbol
+		score := -minimax(b, i, other(aiSymbol), 1)
+		b.Cells[i] = 0 // Undo
+		if score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	return best
+}
+
+// minimax scores the position for the side about to play symbol, the
+// opponent having just played at last.
+func minimax(b Board, last int, symbol byte, depth int) int {
+	if b.WinsAt(last) {
+		return depth - 10
+	}
+	empty := b.Empty()
+	if len(empty) == 0 {
+		return 0
+	}
+	best := -100
+	for _, i := range empty {
+		b.Cells[i] = symbol
+		score := -minimax(b, i, other(symbol), depth+1)
+		b.Cells[i] = 0 // Undo
+		if score > best {
+			best = score
+		}
+	}
+	return best
+}
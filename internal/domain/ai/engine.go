@@ -0,0 +1,20 @@
+package ai
+
+import "tic-tac-toe/internal/domain/game"
+
+// Engine is an AI strategy other than the built-in difficulties, such as
+// an external program. Players pick one by name with "join ai <name>".
+type Engine interface {
+	Name() string
+	// Move returns the position, 0 to 8, that player should play next in g.
+	Move(g *game.Game, player string) (int, error)
+}
+
+// Learner is an Engine that improves from the games it finishes against
+// players.
+type Learner interface {
+	Engine
+	// Learn updates the engine from g, a finished game in which it played
+	// player and made every one of player's moves.
+	Learn(g *game.Game, player string) error
+}
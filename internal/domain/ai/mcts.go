@@ -0,0 +1,177 @@
+package ai
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"tic-tac-toe/internal/domain/game"
+	"time"
+)
+
+// exploration is the UCT constant balancing well-scoring moves against
+// rarely tried ones.
+const exploration = math.Sqrt2
+
+// MCTS is an Engine that picks moves by Monte Carlo tree search: it plays
+// random games from the position, steering them towards the moves that
+// have scored best so far, and plays the move it tried most. Unlike
+// minimax it needs no evaluation of the full game tree, so it works on
+// boards of any size.
+//
+// Each search works on its own copy of the position and its own random
+// source, so searches for different games run in parallel and the game
+// being searched is never touched.
+type MCTS struct {
+	// Playouts is the number of random games per move and Budget the time
+	// allowed for a move; the search stops at whichever comes first. Zero
+	// means no limit, but one of them must be set.
+	Playouts int
+	Budget   time.Duration
+}
+
+// NewMCTS returns an MCTS engine with the given limits per move.
+func NewMCTS(playouts int, budget time.Duration) (*MCTS, error) {
+	if playouts < 0 || budget < 0 || (playouts == 0 && budget == 0) {
+		return nil, errors.New("MCTS needs a positive playout count or time budget")
+	}
+	return &MCTS{Playouts: playouts, Budget: budget}, nil
+}
+
+func (m *MCTS) Name() string {
+	return "mcts"
+}
+
+// Move searches the position of g for player.
+func (m *MCTS) Move(g *game.Game, player string) (int, error) {
+	return m.Search(BoardOf(g), g.SymbolOf(player)[0])
+}
+
+// node is a position in the search tree, reached by playing move as
+// mover.
+type node struct {
+	parent   *node
+	move     int
+	mover    byte
+	children []*node
+	untried  []int
+	visits   int
+	// score sums the playout results for mover: 1 for a win and 0.5 for a
+	// draw.
+	score float64
+}
+
+// Search returns the best position on b for symbol to play.
+func (m *MCTS) Search(b Board, symbol byte) (int, error) {
+	empty := b.Empty()
+	if len(empty) == 0 {
+		return -1, errors.New("no empty cell")
+	}
+	// Winning, or blocking the opponent's win, needs no search.
+	for _, mark := range [2]byte{symbol, other(symbol)} {
+		for _, position := range empty {
+			b.Cells[position] = mark
+			wins := b.WinsAt(position)
+			b.Cells[position] = 0
+			if wins {
+				return position, nil
+			}
+		}
+	}
+
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	root := &node{move: -1, mover: other(symbol), untried: empty}
+	deadline := time.Time{}
+	if m.Budget > 0 {
+		deadline = time.Now().Add(m.Budget)
+	}
+	scratch := b.Clone()
+	for playouts := 0; m.Playouts == 0 || playouts < m.Playouts; playouts++ {
+		// Check the clock every few playouts; it costs more than one.
+		if !deadline.IsZero() && playouts > 0 && playouts%64 == 0 && time.Now().After(deadline) {
+			break
+		}
+		copy(scratch.Cells, b.Cells)
+		m.playout(root, scratch, rng)
+	}
+
+	best := root.children[0]
+	for _, child := range root.children[1:] {
+		if child.visits > best.visits {
+			best = child
+		}
+	}
+	return best.move, nil
+}
+
+// playout runs one iteration of the search from root on board, a scratch
+// copy of the root position, and records the result along the path taken.
+func (m *MCTS) playout(root *node, board Board, rng *rand.Rand) {
+	// Selection: descend through fully expanded nodes by UCT.
+	n := root
+	winner, over := byte(0), false
+	for len(n.untried) == 0 && len(n.children) > 0 {
+		n = n.selectChild()
+		board.Cells[n.move] = n.mover
+		if board.WinsAt(n.move) {
+			winner, over = n.mover, true
+			break
+		}
+	}
+	// Expansion: add one untried move.
+	if !over && len(n.untried) > 0 {
+		i := rng.Intn(len(n.untried))
+		move := n.untried[i]
+		n.untried[i] = n.untried[len(n.untried)-1]
+		n.untried = n.untried[:len(n.untried)-1]
+		board.Cells[move] = other(n.mover)
+		child := &node{parent: n, move: move, mover: other(n.mover)}
+		if board.WinsAt(move) {
+			winner, over = child.mover, true
+		} else {
+			child.untried = board.Empty()
+		}
+		n.children = append(n.children, child)
+		n = child
+	}
+	// Simulation: play randomly to the end.
+	if !over {
+		empty := board.Empty()
+		mover := n.mover
+		for len(empty) > 0 {
+			mover = other(mover)
+			i := rng.Intn(len(empty))
+			move := empty[i]
+			empty[i] = empty[len(empty)-1]
+			empty = empty[:len(empty)-1]
+			board.Cells[move] = mover
+			if board.WinsAt(move) {
+				winner = mover
+				break
+			}
+		}
+	}
+	// Backpropagation.
+	for ; n != nil; n = n.parent {
+		n.visits++
+		switch winner {
+		case n.mover:
+			n.score++
+		case 0:
+			n.score += 0.5
+		}
+	}
+}
+
+// selectChild returns the child with the highest upper confidence bound.
+func (n *node) selectChild() *node {
+	var best *node
+	bestValue := math.Inf(-1)
+	logVisits := math.Log(float64(n.visits))
+	for _, child := range n.children {
+		value := child.score/float64(child.visits) + exploration*math.Sqrt(logVisits/float64(child.visits))
+		if value > bestValue {
+			best, bestValue = child, value
+		}
+	}
+	return best
+}
@@ -0,0 +1,82 @@
+package ai
+
+import (
+	"testing"
+	"time"
+)
+
+// boardFrom builds a width by height board from rows of X, O and -.
+func boardFrom(inARow int, rows ...string) Board {
+	b := NewBoard(len(rows[0]), len(rows), inARow)
+	for r, row := range rows {
+		for c := range row {
+			if row[c] != '-' {
+				b.Cells[r*b.Width+c] = row[c]
+			}
+		}
+	}
+	return b
+}
+
+func TestMCTSSearch(t *testing.T) {
+	tests := []struct {
+		name   string
+		board  Board
+		symbol byte
+		want   int
+	}{
+		{"blocks a row", boardFrom(3, "XX-", "-O-", "---"), 'O', 2},
+		{"blocks a column", boardFrom(3, "O--", "OX-", "--X"), 'X', 6},
+		{"blocks a diagonal", boardFrom(3, "X-O", "-X-", "O--"), 'O', 8},
+		{"wins rather than blocks", boardFrom(3, "XX-", "OO-", "X--"), 'O', 5},
+		{"blocks on a larger board", boardFrom(4,
+			"-------",
+			"-------",
+			"--XXX--",
+			"---O---",
+			"---O---",
+			"-------",
+			"-------",
+		), 'O', -1},
+	}
+	mcts, err := NewMCTS(2000, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := tt.board.Clone()
+			got, err := mcts.Search(tt.board, tt.symbol)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.want == -1 {
+				// X threatens four in a row at either end of its line.
+				if got != 2*7+1 && got != 2*7+5 {
+					t.Errorf("Search = %d, want 15 or 19", got)
+				}
+			} else if got != tt.want {
+				t.Errorf("Search = %d, want %d", got, tt.want)
+			}
+			if string(tt.board.Cells) != string(before.Cells) {
+				t.Error("Search changed the board it was given")
+			}
+		})
+	}
+}
+
+func TestNewMCTSRejectsBadLimits(t *testing.T) {
+	tests := []struct {
+		playouts int
+		budget   time.Duration
+	}{
+		{0, 0},
+		{-1, time.Second},
+		{100, -time.Second},
+	}
+	for _, tt := range tests {
+		if _, err := NewMCTS(tt.playouts, tt.budget); err == nil {
+			t.Errorf("NewMCTS(%d, %s) succeeded", tt.playouts, tt.budget)
+		}
+	}
+}
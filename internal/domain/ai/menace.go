@@ -0,0 +1,233 @@
+package ai
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"tic-tac-toe/internal/domain/game"
+	"time"
+)
+
+// Bead adjustments applied to every move MENACE made in a game, by the
+// game's outcome.
+const (
+	menaceWinBeads  = 3
+	menaceDrawBeads = 1
+	menaceLossBeads = -1
+)
+
+// symmetries lists the eight rotations and reflections of the board as
+// permutations: the transformed board's cell i is the original cell p[i].
+var symmetries = [8][9]int{
+	{0, 1, 2, 3, 4, 5, 6, 7, 8},
+	{6, 3, 0, 7, 4, 1, 8, 5, 2},
+	{8, 7, 6, 5, 4, 3, 2, 1, 0},
+	{2, 5, 8, 1, 4, 7, 0, 3, 6},
+	{2, 1, 0, 5, 4, 3, 8, 7, 6},
+	{0, 3, 6, 1, 4, 7, 2, 5, 8},
+	{6, 7, 8, 3, 4, 5, 0, 1, 2},
+	{8, 5, 2, 7, 4, 1, 6, 3, 0},
+}
+
+// MenaceMemory is everything MENACE has learned: a matchbox for every
+// position it has moved in, keyed by the position's canonical form (the
+// smallest of its symmetries, written with X, O and - as in the engine
+// protocol), holding the beads for each cell, and its record since it was
+// last reset.
+type MenaceMemory struct {
+	Boxes  map[string][9]int `json:"boxes"`
+	Wins   int               `json:"wins"`
+	Draws  int               `json:"draws"`
+	Losses int               `json:"losses"`
+}
+
+// Games returns the number of games MENACE has learned from.
+func (m MenaceMemory) Games() int {
+	return m.Wins + m.Draws + m.Losses
+}
+
+// MenaceRepository persists MENACE's memory between restarts.
+type MenaceRepository interface {
+	// Load returns the saved memory, or empty memory if none was saved.
+	Load() (MenaceMemory, error)
+	Save(memory MenaceMemory) error
+}
+
+// Menace is a learning Engine after Donald Michie's MENACE, the Machine
+// Educable Noughts And Crosses Engine built from matchboxes. Each position
+// has a box of beads, one colour per empty cell; MENACE moves by drawing a
+// bead at random and, after each game, adds beads for the moves it made if
+// it won or drew and takes them away if it lost, so moves that worked
+// become more likely. Symmetric positions share a box.
+type Menace struct {
+	repo MenaceRepository
+
+	mu     sync.Mutex
+	memory MenaceMemory
+	rng    *rand.Rand
+}
+
+// NewMenace returns MENACE with the memory saved in repo.
+func NewMenace(repo MenaceRepository) (*Menace, error) {
+	memory, err := repo.Load()
+	if err != nil {
+		return nil, err
+	}
+	if memory.Boxes == nil {
+		memory.Boxes = make(map[string][9]int)
+	}
+	return &Menace{repo: repo, memory: memory, rng: rand.New(rand.NewSource(time.Now().UnixNano()))}, nil
+}
+
+func (m *Menace) Name() string {
+	return "menace"
+}
+
+// Move draws a bead from the box for the position of g.
+func (m *Menace) Move(g *game.Game, player string) (int, error) {
+	key, perm := canonical(g.Board)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	box := m.box(key)
+	total := 0
+	for _, beads := range box {
+		total += beads
+	}
+	if total == 0 {
+		return -1, errors.New("no empty cell")
+	}
+	draw := m.rng.Intn(total)
+	for cell, beads := range box {
+		if draw < beads {
+			return perm[cell], nil
+		}
+		draw -= beads
+	}
+	return -1, errors.New("no bead drawn")
+}
+
+// Learn rewards or punishes the moves player made in g, a finished game,
+// and saves what was learned.
+func (m *Menace) Learn(g *game.Game, player string) error {
+	adjust := menaceDrawBeads
+	switch g.Winner {
+	case player:
+		adjust = menaceWinBeads
+	case "":
+	default:
+		adjust = menaceLossBeads
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var board [9]string
+	for i := range board {
+		board[i] = " "
+	}
+	for _, move := range g.Moves {
+		if move.Player == player {
+			key, perm := canonical(board)
+			box := m.box(key)
+			for cell, position := range perm {
+				if position == move.Position {
+					box[cell] = max(box[cell]+adjust, 0)
+					break
+				}
+			}
+			if box == ([9]int{}) {
+				// MENACE gave up on every move here; start the box afresh
+				// rather than leave it unable to play.
+				delete(m.memory.Boxes, key)
+				box = m.box(key)
+			}
+			m.memory.Boxes[key] = box
+		}
+		board[move.Position] = g.SymbolOf(move.Player)
+	}
+	switch adjust {
+	case menaceWinBeads:
+		m.memory.Wins++
+	case menaceDrawBeads:
+		m.memory.Draws++
+	default:
+		m.memory.Losses++
+	}
+	return m.repo.Save(m.memory)
+}
+
+// Reset forgets everything MENACE has learned.
+func (m *Menace) Reset() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.memory = MenaceMemory{Boxes: make(map[string][9]int)}
+	return m.repo.Save(m.memory)
+}
+
+// Memory returns a copy of what MENACE has learned.
+func (m *Menace) Memory() MenaceMemory {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	memory := m.memory
+	memory.Boxes = make(map[string][9]int, len(m.memory.Boxes))
+	for key, box := range m.memory.Boxes {
+		memory.Boxes[key] = box
+	}
+	return memory
+}
+
+// Beads returns the beads MENACE holds for each cell of board, in board's
+// own orientation, and whether it has a box for the position yet.
+func (m *Menace) Beads(board [9]string) ([9]int, bool) {
+	key, perm := canonical(board)
+	m.mu.Lock()
+	box, ok := m.memory.Boxes[key]
+	m.mu.Unlock()
+	var beads [9]int
+	for cell, position := range perm {
+		beads[position] = box[cell]
+	}
+	return beads, ok
+}
+
+// box returns the box for the canonical position key, filling a new box
+// with beads for each empty cell: more early in the game, when there are
+// more moves to choose from, as in the original. The caller must hold m.mu.
+func (m *Menace) box(key string) [9]int {
+	if box, ok := m.memory.Boxes[key]; ok {
+		return box
+	}
+	var box [9]int
+	marks := 0
+	for _, c := range key {
+		if c != '-' {
+			marks++
+		}
+	}
+	beads := max(4-marks/2, 1)
+	for cell, c := range key {
+		if c == '-' {
+			box[cell] = beads
+		}
+	}
+	m.memory.Boxes[key] = box
+	return box
+}
+
+// canonical returns the smallest of the eight symmetries of board, written
+// with X, O and -, and the permutation producing it: the canonical cell i
+// is board cell perm[i].
+func canonical(board [9]string) (string, [9]int) {
+	best, bestPerm := "", symmetries[0]
+	for _, perm := range symmetries {
+		var key [9]byte
+		for i, position := range perm {
+			key[i] = '-'
+			if board[position] != " " {
+				key[i] = board[position][0]
+			}
+		}
+		if best == "" || string(key[:]) < best {
+			best, bestPerm = string(key[:]), perm
+		}
+	}
+	return best, bestPerm
+}
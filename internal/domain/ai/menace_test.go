@@ -0,0 +1,221 @@
+package ai
+
+import (
+	"testing"
+
+	"tic-tac-toe/internal/domain/game"
+)
+
+// memoryMenaceRepository keeps MENACE's memory in memory.
+type memoryMenaceRepository struct {
+	memory MenaceMemory
+	saves  int
+}
+
+func (r *memoryMenaceRepository) Load() (MenaceMemory, error) {
+	return r.memory, nil
+}
+
+func (r *memoryMenaceRepository) Save(memory MenaceMemory) error {
+	r.memory = memory
+	r.saves++
+	return nil
+}
+
+// transform returns board as seen through the symmetry perm.
+func transform(board [9]string, perm [9]int) [9]string {
+	var out [9]string
+	for i, position := range perm {
+		out[i] = board[position]
+	}
+	return out
+}
+
+func boardOf(marks map[int]string) [9]string {
+	board := [9]string{" ", " ", " ", " ", " ", " ", " ", " ", " "}
+	for position, mark := range marks {
+		board[position] = mark
+	}
+	return board
+}
+
+func TestSymmetries(t *testing.T) {
+	set := make(map[[9]int]bool)
+	for i, perm := range symmetries {
+		var seen [9]bool
+		for _, position := range perm {
+			if position < 0 || position > 8 || seen[position] {
+				t.Fatalf("symmetry %d %v is not a permutation of the cells", i, perm)
+			}
+			seen[position] = true
+		}
+		if perm[4] != 4 {
+			t.Errorf("symmetry %d moves the centre", i)
+		}
+		set[perm] = true
+	}
+	if len(set) != len(symmetries) {
+		t.Fatalf("symmetries are not distinct: %d of %d", len(set), len(symmetries))
+	}
+	if !set[[9]int{0, 1, 2, 3, 4, 5, 6, 7, 8}] {
+		t.Error("symmetries lack the identity")
+	}
+	for _, a := range symmetries {
+		for _, b := range symmetries {
+			var composed [9]int
+			for i := range composed {
+				composed[i] = b[a[i]]
+			}
+			if !set[composed] {
+				t.Errorf("composing %v and %v gives %v, which is not a symmetry", a, b, composed)
+			}
+		}
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	tests := []struct {
+		name  string
+		board [9]string
+		want  string
+	}{
+		{"empty", boardOf(nil), "---------"},
+		{"corner", boardOf(map[int]string{0: "X"}), "--------X"},
+		{"edge", boardOf(map[int]string{1: "X"}), "-------X-"},
+		{"centre", boardOf(map[int]string{4: "X"}), "----X----"},
+		{"corner and centre", boardOf(map[int]string{2: "X", 4: "O"}), "----O---X"},
+		{"corner and adjacent edge", boardOf(map[int]string{0: "X", 1: "O"}), "-------OX"},
+		{"full", boardOf(map[int]string{0: "X", 1: "O", 2: "X", 3: "X", 4: "O", 5: "O", 6: "O", 7: "X", 8: "X"}), "OXXXOOXOX"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, sym := range symmetries {
+				board := transform(tt.board, sym)
+				key, perm := canonical(board)
+				if key != tt.want {
+					t.Errorf("symmetry %d: canonical(%q) = %q, want %q", i, board, key, tt.want)
+				}
+				for cell, position := range perm {
+					want := board[position]
+					if want == " " {
+						want = "-"
+					}
+					if string(key[cell]) != want {
+						t.Errorf("symmetry %d: canonical cell %d is %q, but board cell perm[%d] = %d is %q", i, cell, key[cell], cell, position, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+// playMenaceGame replays moves, with "menace" playing X and "alice" O, and
+// returns the game and the board before each of player's moves.
+func playMenaceGame(t *testing.T, moves []int, player string) (*game.Game, [][9]string) {
+	t.Helper()
+	players := []string{"menace", "alice"}
+	var recorded []game.Move
+	var before [][9]string
+	board := boardOf(nil)
+	for i, position := range moves {
+		mover := players[i%2]
+		if mover == player {
+			before = append(before, board)
+		}
+		recorded = append(recorded, game.Move{Player: mover, Position: position})
+		board[position] = []string{"X", "O"}[i%2]
+	}
+	g, err := game.Replay(replayLogger, "game-1", players, false, recorded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g, before
+}
+
+func TestMenaceLearn(t *testing.T) {
+	tests := []struct {
+		name   string
+		moves  []int
+		player string
+		// want is the beads left for each move player made, in order.
+		want                []int
+		wins, draws, losses int
+	}{
+		{"win as X", []int{0, 3, 1, 4, 2}, "menace", []int{7, 6, 5}, 1, 0, 0},
+		{"win as O", []int{0, 4, 1, 2, 8, 6}, "alice", []int{7, 6, 5}, 1, 0, 0},
+		{"draw", []int{0, 4, 8, 2, 6, 3, 5, 7, 1}, "menace", []int{5, 4, 3, 2, 2}, 0, 1, 0},
+		{"loss", []int{0, 4, 1, 2, 3, 6}, "menace", []int{3, 2, 1}, 0, 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &memoryMenaceRepository{}
+			m, err := NewMenace(repo)
+			if err != nil {
+				t.Fatal(err)
+			}
+			g, before := playMenaceGame(t, tt.moves, tt.player)
+			if err := m.Learn(g, tt.player); err != nil {
+				t.Fatal(err)
+			}
+			var played []int
+			for _, move := range g.Moves {
+				if move.Player == tt.player {
+					played = append(played, move.Position)
+				}
+			}
+			for i, board := range before {
+				beads, ok := m.Beads(board)
+				if !ok {
+					t.Fatalf("move %d: no box for %q", i+1, board)
+				}
+				if beads[played[i]] != tt.want[i] {
+					t.Errorf("move %d: %d beads for cell %d, want %d", i+1, beads[played[i]], played[i], tt.want[i])
+				}
+			}
+			memory := repo.memory
+			if memory.Wins != tt.wins || memory.Draws != tt.draws || memory.Losses != tt.losses {
+				t.Errorf("record %d/%d/%d, want %d/%d/%d", memory.Wins, memory.Draws, memory.Losses, tt.wins, tt.draws, tt.losses)
+			}
+			if repo.saves != 1 {
+				t.Errorf("saved %d times, want 1", repo.saves)
+			}
+		})
+	}
+}
+
+func TestMenaceLearnFloorsAndRefills(t *testing.T) {
+	// In the loss below MENACE's last move is cell 3 of this position.
+	moves := []int{0, 4, 1, 2, 3, 6}
+	tests := []struct {
+		name string
+		// seed is the box for the position of MENACE's last move, in the
+		// board's orientation.
+		seed [9]int
+		want [9]int
+	}{
+		{"floors at zero", [9]int{3: 0, 5: 2}, [9]int{5: 2}},
+		{"refills an emptied box", [9]int{3: 1}, [9]int{3: 2, 5: 2, 6: 2, 7: 2, 8: 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, before := playMenaceGame(t, moves, "menace")
+			last := before[len(before)-1]
+			key, perm := canonical(last)
+			var box [9]int
+			for cell, position := range perm {
+				box[cell] = tt.seed[position]
+			}
+			repo := &memoryMenaceRepository{memory: MenaceMemory{Boxes: map[string][9]int{key: box}}}
+			m, err := NewMenace(repo)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := m.Learn(g, "menace"); err != nil {
+				t.Fatal(err)
+			}
+			if beads, _ := m.Beads(last); beads != tt.want {
+				t.Errorf("beads %v, want %v", beads, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package ai
+
+import (
+	"io"
+	"log/slog"
+	"tic-tac-toe/internal/domain/game"
+)
+
+// replayLogger discards the log of the replays Review makes, which would
+// otherwise announce the game's result again.
+var replayLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// Verdict grades a move against perfect play.
+type Verdict string
+
+const (
+	// Best moves keep the best outcome available, as fast as possible.
+	Best Verdict = "best"
+	// Inaccuracy moves keep the outcome but win more slowly or lose more
+	// quickly than the best move.
+	Inaccuracy Verdict = "inaccuracy"
+	// Blunder moves give up a better outcome, such as turning a drawn
+	// position into a lost one.
+	Blunder Verdict = "blunder"
+)
+
+// MoveReview grades one move of a game. Played and Best are the values of
+// the move made and of the best move in the position.
+type MoveReview struct {
+	Move    game.Move
+	Symbol  string
+	Played  MoveValue
+	Best    MoveValue
+	Verdict Verdict
+}
+
+// Review replays the moves of g from the empty board and grades each one
+// by solving the position it was played in.
+func Review(g *game.Game) ([]MoveReview, error) {
+	reviews := make([]MoveReview, 0, len(g.Moves))
+	for i, move := range g.Moves {
+		replay, err := game.Replay(replayLogger, g.ID, g.Players, g.IsAIGame, g.Moves[:i])
+		if err != nil {
+			return nil, err
+		}
+		values := Analyze(replay)
+		review := MoveReview{Move: move, Symbol: replay.SymbolOf(move.Player), Best: values[0], Verdict: Best}
+		for _, v := range values {
+			if v.Position == move.Position {
+				review.Played = v
+			}
+		}
+		switch {
+		case review.Played.Outcome < review.Best.Outcome:
+			review.Verdict = Blunder
+		case better(review.Best, review.Played):
+			review.Verdict = Inaccuracy
+		}
+		reviews = append(reviews, review)
+	}
+	return reviews, nil
+}
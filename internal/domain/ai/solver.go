@@ -0,0 +1,144 @@
+package ai
+
+import (
+	"sort"
+	"sync"
+	"tic-tac-toe/internal/domain/game"
+)
+
+// Outcome is how a position ends with perfect play by both sides, for the
+// player it concerns.
+type Outcome int
+
+const (
+	Loss Outcome = iota - 1
+	Draw
+	Win
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case Win:
+		return "win"
+	case Loss:
+		return "loss"
+	}
+	return "draw"
+}
+
+// MoveValue is the result of playing at Position, 0 to 8, when both sides
+// play perfectly from then on: the Outcome for the player making the move
+// and how many moves, by both sides and counting this one, the game lasts.
+type MoveValue struct {
+	Position int
+	Outcome  Outcome
+	Moves    int
+}
+
+// value is a solved position: the outcome for the player to move and the
+// number of moves left.
+type value struct {
+	outcome Outcome
+	moves   int
+}
+
+// solved holds the value of every position reachable from the empty
+// board, solved once on first use and read-only after that.
+var solved struct {
+	once   sync.Once
+	values map[[9]byte]value
+}
+
+// Analyze solves the position of g and returns the value of every empty
+// cell for the player whose turn it is, best first: wins before draws
+// before losses, quicker wins and slower losses first.
+func Analyze(g *game.Game) []MoveValue {
+	solved.once.Do(func() {
+		solved.values = make(map[[9]byte]value)
+		solve(NewBoard(3, 3, 3), 'X', solved.values)
+	})
+	b := BoardOf(g)
+	symbol := g.SymbolOf(g.CurrentTurn)[0]
+	var values []MoveValue
+	for _, position := range b.Empty() {
+		b.Cells[position] = symbol
+		mv := MoveValue{Position: position, Outcome: Win, Moves: 1}
+		if !b.WinsAt(position) {
+			v := lookup(b, other(symbol))
+			mv.Outcome, mv.Moves = -v.outcome, v.moves+1
+		}
+		b.Cells[position] = 0
+		values = append(values, mv)
+	}
+	sort.SliceStable(values, func(i, j int) bool {
+		return better(values[i], values[j])
+	})
+	return values
+}
+
+// Solve returns the best move for the player whose turn it is in g; see
+// Analyze. It returns a MoveValue with Position -1 if the board is full.
+func Solve(g *game.Game) MoveValue {
+	values := Analyze(g)
+	if len(values) == 0 {
+		return MoveValue{Position: -1}
+	}
+	return values[0]
+}
+
+// better reports whether a is a better move than b.
+func better(a, b MoveValue) bool {
+	if a.Outcome != b.Outcome {
+		return a.Outcome > b.Outcome
+	}
+	switch a.Outcome {
+	case Win:
+		return a.Moves < b.Moves
+	case Loss:
+		return a.Moves > b.Moves
+	}
+	return false
+}
+
+// lookup returns the value of b, which has no winner yet, for symbol to
+// move.
+func lookup(b Board, symbol byte) value {
+	var key [9]byte
+	copy(key[:], b.Cells)
+	if v, ok := solved.values[key]; ok {
+		return v
+	}
+	return solve(b.Clone(), symbol, nil)
+}
+
+// solve computes the value of b, which has no winner yet, for symbol to
+// move by searching every continuation, storing the values found in
+// values unless it is nil.
+func solve(b Board, symbol byte, values map[[9]byte]value) value {
+	var key [9]byte
+	copy(key[:], b.Cells)
+	if v, ok := values[key]; ok {
+		return v
+	}
+	best := MoveValue{Position: -1}
+	for _, position := range b.Empty() {
+		b.Cells[position] = symbol
+		mv := MoveValue{Position: position, Outcome: Win, Moves: 1}
+		if !b.WinsAt(position) {
+			v := solve(b, other(symbol), values)
+			mv.Outcome, mv.Moves = -v.outcome, v.moves+1
+		}
+		b.Cells[position] = 0
+		if best.Position == -1 || better(mv, best) {
+			best = mv
+		}
+	}
+	v := value{outcome: best.Outcome, moves: best.Moves}
+	if best.Position == -1 {
+		v = value{outcome: Draw}
+	}
+	if values != nil {
+		values[key] = v
+	}
+	return v
+}
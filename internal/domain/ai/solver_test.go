@@ -0,0 +1,114 @@
+package ai
+
+import (
+	"testing"
+
+	"tic-tac-toe/internal/domain/game"
+)
+
+var lines = [8][3]int{
+	{0, 1, 2}, {3, 4, 5}, {6, 7, 8},
+	{0, 3, 6}, {1, 4, 7}, {2, 5, 8},
+	{0, 4, 8}, {2, 4, 6},
+}
+
+// bruteForce plays out every continuation of board with symbol to move and
+// returns the value of the best move, preferring quick wins and slow
+// losses, with no memo and none of the solver's code.
+func bruteForce(board [9]byte, symbol byte) (Outcome, int) {
+	opponent := byte('X')
+	if symbol == 'X' {
+		opponent = 'O'
+	}
+	found := false
+	var best MoveValue
+	for i := range board {
+		if board[i] != 0 {
+			continue
+		}
+		board[i] = symbol
+		mv := MoveValue{Position: i, Outcome: Win, Moves: 1}
+		if !won(board, symbol) {
+			outcome, moves := bruteForce(board, opponent)
+			mv.Outcome, mv.Moves = -outcome, moves+1
+		}
+		board[i] = 0
+		if !found || better(mv, best) {
+			best, found = mv, true
+		}
+	}
+	if !found {
+		return Draw, 0
+	}
+	return best.Outcome, best.Moves
+}
+
+func won(board [9]byte, symbol byte) bool {
+	for _, line := range lines {
+		if board[line[0]] == symbol && board[line[1]] == symbol && board[line[2]] == symbol {
+			return true
+		}
+	}
+	return false
+}
+
+func TestAnalyzeAgreesWithBruteForce(t *testing.T) {
+	tests := []struct {
+		name  string
+		moves []int
+	}{
+		{"empty board", nil},
+		{"corner opening", []int{0}},
+		{"centre opening", []int{4}},
+		{"edge reply to corner", []int{0, 1}},
+		{"double threat available", []int{0, 4, 8, 2}},
+		{"must block", []int{0, 4, 1}},
+		{"win or block", []int{0, 3, 1, 4}},
+		{"lost position", []int{4, 1, 0, 8, 6}},
+		{"one cell left", []int{0, 1, 2, 4, 3, 5, 7, 6}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := replayPositions(t, tt.moves)
+			symbol := g.SymbolOf(g.CurrentTurn)[0]
+			opponent := other(symbol)
+			var board [9]byte
+			copy(board[:], BoardOf(g).Cells)
+			values := Analyze(g)
+			if len(values) != 9-len(tt.moves) {
+				t.Fatalf("Analyze returned %d moves, want %d", len(values), 9-len(tt.moves))
+			}
+			for _, v := range values {
+				board[v.Position] = symbol
+				want := MoveValue{Position: v.Position, Outcome: Win, Moves: 1}
+				if !won(board, symbol) {
+					outcome, moves := bruteForce(board, opponent)
+					want.Outcome, want.Moves = -outcome, moves+1
+				}
+				board[v.Position] = 0
+				if v != want {
+					t.Errorf("move %d: Analyze gives %+v, brute force %+v", v.Position+1, v, want)
+				}
+			}
+			outcome, moves := bruteForce(board, symbol)
+			if best := Solve(g); best.Outcome != outcome || best.Moves != moves {
+				t.Errorf("Solve = %+v, brute force best is %v in %d", best, outcome, moves)
+			}
+		})
+	}
+}
+
+// replayPositions plays moves, alternating between X and O.
+func replayPositions(t *testing.T, moves []int) *game.Game {
+	t.Helper()
+	players := []string{"xavier", "olive"}
+	var recorded []game.Move
+	for i, position := range moves {
+		recorded = append(recorded, game.Move{Player: players[i%2], Position: position})
+	}
+	g, err := game.Replay(replayLogger, "game-1", players, false, recorded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
@@ -0,0 +1,18 @@
+package ban
+
+import (
+	"net"
+	"time"
+)
+
+// Ban blocks a username or an IP address from logging in.
+type Ban struct {
+	Target   string
+	BannedBy string
+	BannedAt time.Time
+}
+
+// IsIP reports whether the ban targets an IP address rather than a username.
+func (b *Ban) IsIP() bool {
+	return net.ParseIP(b.Target) != nil
+}
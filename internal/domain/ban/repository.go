@@ -0,0 +1,8 @@
+package ban
+
+type BanRepository interface {
+	Save(ban *Ban) error
+	Delete(target string) error
+	IsBanned(target string) bool
+	All() ([]*Ban, error)
+}
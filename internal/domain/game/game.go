@@ -2,10 +2,52 @@ package game
 
 import (
 	"errors"
-	"log"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
 	"strings"
+	"time"
 )
 
+// AIPlayer is the AI's name in the Players of an AI game.
+const AIPlayer = "AI"
+
+// IsAIName reports whether name is one the AI plays under: AIPlayer, or an
+// exhibition player such as "AI hard (X)", in any case. No account may
+// take such a name, or a player could not be told apart from the AI.
+func IsAIName(name string) bool {
+	name = strings.ToUpper(name)
+	return name == AIPlayer || strings.HasPrefix(name, AIPlayer+" ")
+}
+
+// State is where a game is in its life cycle. Every game starts
+// InProgress with the players taking turns in the order of Players, the AI
+// included, and the move that completes a line or fills the board takes it
+// to Won or Drawn, after which no move is accepted.
+type State string
+
+const (
+	InProgress State = "in-progress"
+	Won        State = "won"
+	Drawn      State = "drawn"
+)
+
+// Errors returned by MakeMove for moves the game does not accept.
+var (
+	ErrGameOver        = errors.New("game is already over")
+	ErrNotYourTurn     = errors.New("not your turn")
+	ErrInvalidPosition = errors.New("invalid position")
+	ErrCellTaken       = errors.New("cell already taken")
+)
+
+// Move is one mark placed in a game.
+type Move struct {
+	Player   string
+	Position int
+	At       time.Time
+}
+
 type Game struct {
 	ID          string
 	Board       [9]string
@@ -14,69 +56,119 @@ type Game struct {
 	Winner      string
 	IsDraw      bool
 	IsAIGame    bool
+	// AIDifficulty is the difficulty or engine the AI plays at in AI games.
+	AIDifficulty string
+	// AILevels maps each player of an exhibition game, where two AIs play
+	// each other, to the difficulty or engine it plays at.
+	AILevels map[string]string
+	// TurnStartedAt is when the player in CurrentTurn became due to move.
+	TurnStartedAt time.Time
+	// LastMove is the position of the most recent mark, or -1 before the
+	// first move.
+	LastMove int
+	// Moves lists every move in the order played, so the game can be
+	// replayed.
+	Moves []Move
+	// Assisted is set once the player of an AI game asks for a hint or an
+	// analysis of the position; such games earn no points.
+	Assisted bool
+	// EngineFailed is set once an engine fails to move and the built-in AI
+	// moves for it, so the engine does not learn from moves it never chose.
+	EngineFailed bool
+}
+
+var winningLines = [][3]int{
+	{0, 1, 2}, {3, 4, 5}, {6, 7, 8}, // Rows
+	{0, 3, 6}, {1, 4, 7}, {2, 5, 8}, // Columns
+	{0, 4, 8}, {2, 4, 6}, // Diagonals
 }
 
 func NewGame(id string, players []string, isAIGame bool) *Game {
 	return &Game{
-		ID:          id,
-		Board:       [9]string{" ", " ", " ", " ", " ", " ", " ", " ", " "},
-		Players:     players,
-		CurrentTurn: players[0],
-		IsAIGame:    isAIGame,
+		ID:            id,
+		Board:         [9]string{" ", " ", " ", " ", " ", " ", " ", " ", " "},
+		Players:       players,
+		CurrentTurn:   players[0],
+		IsAIGame:      isAIGame,
+		TurnStartedAt: time.Now(),
+		LastMove:      -1,
 	}
 }
 
-func (g *Game) MakeMove(player string, position int) error {
-	log.Printf("MakeMove: player=%s, position=%d, CurrentTurn=%s, IsAIGame=%v", player, position, g.CurrentTurn, g.IsAIGame)
-	if g.Winner != "" || g.IsDraw {
-		log.Println("MakeMove: game is already over")
-		return errors.New("game is already over")
+// MakeMove places player's mark at position and passes the turn to the
+// other player. Players move in the order of Players in every game, so in
+// AI games AIPlayer may move first or second. logger carries the context of
+// the request the move is part of, at least the game_id: for a move sent by
+// a client, the player's Logger, so entries show its remote address.
+func (g *Game) MakeMove(logger *slog.Logger, player string, position int) error {
+	logger = logger.With("player", player)
+	if g.Over() {
+		logger.Debug("move rejected: game is already over", "position", position)
+		return ErrGameOver
 	}
-	// Allow moves in AI games regardless of CurrentTurn, as GameService handles AI turns
-	if !g.IsAIGame && g.CurrentTurn != player {
-		log.Println("MakeMove: not your turn")
-		return errors.New("not your turn")
+	if g.CurrentTurn != player {
+		logger.Debug("move rejected: not your turn", "position", position, "current_turn", g.CurrentTurn)
+		return ErrNotYourTurn
 	}
 	if position < 0 || position > 8 {
-		log.Println("MakeMove: invalid position (out of bounds)")
-		return errors.New("invalid position")
+		logger.Debug("move rejected: position out of bounds", "position", position)
+		return ErrInvalidPosition
 	}
 	if g.Board[position] != " " {
-		log.Printf("MakeMove: cell %d already taken (value: %s)", position, g.Board[position])
-		return errors.New("cell already taken")
-	}
-	symbol := "X"
-	if g.Players[0] != player {
-		symbol = "O"
+		logger.Debug("move rejected: cell already taken", "position", position)
+		return ErrCellTaken
 	}
+	symbol := g.SymbolOf(player)
+	now := time.Now()
 	g.Board[position] = symbol
-	log.Printf("MakeMove: placed %s at position %d", symbol, position)
+	g.LastMove = position
+	g.Moves = append(g.Moves, Move{Player: player, Position: position, At: now})
+	g.TurnStartedAt = now
+	logger.Debug("move placed", "position", position, "symbol", symbol)
 	if g.CheckWin(symbol) {
 		g.Winner = player
-		log.Printf("MakeMove: %s wins", player)
+		logger.Info("game won")
 	} else if g.CheckDraw() {
 		g.IsDraw = true
-		log.Println("MakeMove: game is a draw")
+		logger.Info("game drawn")
 	} else {
-		// Update CurrentTurn only for two-player games
-		if !g.IsAIGame {
-			g.CurrentTurn = g.Players[0]
-			if g.CurrentTurn == player {
-				g.CurrentTurn = g.Players[1]
-			}
-		}
-		log.Printf("MakeMove: updated CurrentTurn to %s", g.CurrentTurn)
+		g.CurrentTurn = g.Opponent(player)
+		logger.Debug("turn passed", "current_turn", g.CurrentTurn)
 	}
 	return nil
 }
 
-func (g *Game) CheckWin(symbol string) bool {
-	winningCombos := [][3]int{
-		{0, 1, 2}, {3, 4, 5}, {6, 7, 8}, // Rows
-		{0, 3, 6}, {1, 4, 7}, {2, 5, 8}, // Columns
-		{0, 4, 8}, {2, 4, 6}, // Diagonals
+// Replay rebuilds a game by playing moves in order from the empty board,
+// checking each one as MakeMove does and logging to logger.
+func Replay(logger *slog.Logger, id string, players []string, isAIGame bool, moves []Move) (*Game, error) {
+	g := NewGame(id, players, isAIGame)
+	for i, move := range moves {
+		if err := g.MakeMove(logger, move.Player, move.Position); err != nil {
+			return nil, fmt.Errorf("move %d: %w", i+1, err)
+		}
+		g.Moves[i].At = move.At
 	}
-	for _, combo := range winningCombos {
+	return g, nil
+}
+
+// State reports whether the game is in progress, won or drawn.
+func (g *Game) State() State {
+	switch {
+	case g.Winner != "":
+		return Won
+	case g.IsDraw:
+		return Drawn
+	}
+	return InProgress
+}
+
+// Over reports whether the game has finished.
+func (g *Game) Over() bool {
+	return g.State() != InProgress
+}
+
+func (g *Game) CheckWin(symbol string) bool {
+	for _, combo := range winningLines {
 		if g.Board[combo[0]] == symbol && g.Board[combo[1]] == symbol && g.Board[combo[2]] == symbol {
 			return true
 		}
@@ -84,6 +176,17 @@ func (g *Game) CheckWin(symbol string) bool {
 	return false
 }
 
+// WinningLine returns the positions of the completed line, or nil if no
+// one has won.
+func (g *Game) WinningLine() []int {
+	for _, combo := range winningLines {
+		if g.Board[combo[0]] != " " && g.Board[combo[0]] == g.Board[combo[1]] && g.Board[combo[1]] == g.Board[combo[2]] {
+			return combo[:]
+		}
+	}
+	return nil
+}
+
 func (g *Game) CheckDraw() bool {
 	for _, cell := range g.Board {
 		if cell == " " {
@@ -93,6 +196,48 @@ func (g *Game) CheckDraw() bool {
 	return true
 }
 
+// SymbolOf returns the mark placed by player: X for the first player, O otherwise.
+func (g *Game) SymbolOf(player string) string {
+	if g.Players[0] == player {
+		return "X"
+	}
+	return "O"
+}
+
+// Clone returns a copy of g that shares nothing with it, so it can be read
+// while g is played.
+func (g *Game) Clone() *Game {
+	clone := *g
+	clone.Players = slices.Clone(g.Players)
+	clone.AILevels = maps.Clone(g.AILevels)
+	clone.Moves = slices.Clone(g.Moves)
+	return &clone
+}
+
+// IsExhibition reports whether the game is played between two AIs.
+func (g *Game) IsExhibition() bool {
+	return len(g.AILevels) > 0
+}
+
+// Opponent returns the other participant in the game.
+func (g *Game) Opponent(player string) string {
+	if g.Players[0] == player {
+		return g.Players[1]
+	}
+	return g.Players[0]
+}
+
+// MoveCount returns the number of marks on the board.
+func (g *Game) MoveCount() int {
+	count := 0
+	for _, cell := range g.Board {
+		if cell != " " {
+			count++
+		}
+	}
+	return count
+}
+
 // DisplayString formats the board for terminal output.
 func (g *Game) DisplayString() string {
 	var sb strings.Builder
@@ -1,7 +1,11 @@
 package game
 
+// GameRepository holds the games in play. It stores and returns copies, so a
+// game found while another goroutine plays it is never changed under the
+// reader; a change is seen once it is saved.
 type GameRepository interface {
 	Save(game *Game) error
 	FindByID(id string) (*Game, error)
 	Delete(id string) error
+	All() ([]*Game, error)
 }
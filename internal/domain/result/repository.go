@@ -0,0 +1,8 @@
+package result
+
+import "time"
+
+type ResultRepository interface {
+	Save(result *Result) error
+	Since(t time.Time) ([]*Result, error)
+}
@@ -0,0 +1,31 @@
+package result
+
+import "time"
+
+type Mode string
+
+const (
+	ModeAI        Mode = "ai"
+	ModeTwoPlayer Mode = "two-player"
+	// ModeBot is any game a bot took part in. These games are rated with
+	// Elo and award no points.
+	ModeBot Mode = "bot"
+)
+
+type Outcome string
+
+const (
+	Win  Outcome = "win"
+	Loss Outcome = "loss"
+	Draw Outcome = "draw"
+)
+
+// Result is one player's entry in the log of finished games.
+type Result struct {
+	GameID     string
+	Username   string
+	Mode       Mode
+	Outcome    Outcome
+	Points     int
+	FinishedAt time.Time
+}
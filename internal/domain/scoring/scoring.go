@@ -0,0 +1,178 @@
+package scoring
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"tic-tac-toe/internal/domain/result"
+)
+
+// OutcomePoints is the points awarded for each outcome. A negative Loss is a
+// penalty.
+type OutcomePoints struct {
+	Win  int `json:"win"`
+	Draw int `json:"draw"`
+	Loss int `json:"loss"`
+}
+
+// StreakBonus awards extra points on the win that brings the streak to
+// exactly Streak.
+type StreakBonus struct {
+	Streak int `json:"streak"`
+	Points int `json:"points"`
+}
+
+// Rules is the scoring economy. AI points are keyed by AI difficulty or
+// engine name; DefaultAI applies to those not listed, such as engines
+// added with -engine.
+type Rules struct {
+	TwoPlayer     OutcomePoints            `json:"two_player"`
+	AI            map[string]OutcomePoints `json:"ai"`
+	DefaultAI     OutcomePoints            `json:"default_ai"`
+	StreakBonuses []StreakBonus            `json:"streak_bonuses"`
+	FloorAtZero   bool                     `json:"floor_at_zero"`
+	// BotKFactor is the Elo K-factor of games a bot takes part in.
+	// AIRatings are the fixed ratings a bot is measured against when it
+	// plays the AI, keyed like AI; unlisted levels are rated
+	// DefaultAIRating.
+	BotKFactor      int            `json:"bot_k_factor"`
+	AIRatings       map[string]int `json:"ai_ratings"`
+	DefaultAIRating int            `json:"default_ai_rating"`
+}
+
+// InitialRating is the Elo rating of a player's first bot game.
+const InitialRating = 1500
+
+// Default returns the built-in rules: 2 points for a two-player win, 1 for a
+// win against any AI, +5 at a 3-game streak and +10 at a 5-game streak.
+// Bot games use a K-factor of 32, and engines without a rating of their own
+// are rated InitialRating.
+func Default() *Rules {
+	return &Rules{
+		TwoPlayer: OutcomePoints{Win: 2},
+		AI: map[string]OutcomePoints{
+			"easy":   {Win: 1},
+			"medium": {Win: 1},
+			"hard":   {Win: 1},
+			"mcts":   {Win: 1},
+			"menace": {Win: 1},
+		},
+		DefaultAI: OutcomePoints{Win: 1},
+		StreakBonuses: []StreakBonus{
+			{Streak: 3, Points: 5},
+			{Streak: 5, Points: 10},
+		},
+		FloorAtZero: true,
+		BotKFactor:  32,
+		AIRatings: map[string]int{
+			"easy":   800,
+			"medium": 1200,
+			"hard":   1800,
+			"mcts":   1800,
+			"menace": 1000,
+		},
+		DefaultAIRating: InitialRating,
+	}
+}
+
+// Load reads rules from a JSON file. Settings missing from the file keep
+// their default values, down to the single outcomes of an AI level.
+func Load(path string) (*Rules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	rules := Default()
+	if err := json.Unmarshal(data, rules); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	// Decoding replaces whole map entries, so an AI level given only a win
+	// would lose its default draw and loss points. Decode each level again
+	// over its defaults.
+	var levels struct {
+		AI map[string]json.RawMessage `json:"ai"`
+	}
+	if err := json.Unmarshal(data, &levels); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	defaults := Default().AI
+	for level, raw := range levels.AI {
+		points, ok := defaults[level]
+		if !ok {
+			points = rules.DefaultAI
+		}
+		if err := json.Unmarshal(raw, &points); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", path, err)
+		}
+		rules.AI[level] = points
+	}
+	if rules.BotKFactor < 1 {
+		return nil, errors.New("bot_k_factor must be at least 1")
+	}
+	if rules.DefaultAIRating < 1 {
+		return nil, errors.New("default_ai_rating must be at least 1")
+	}
+	for _, bonus := range rules.StreakBonuses {
+		if bonus.Streak < 1 {
+			return nil, errors.New("streak bonus must be for a streak of at least 1")
+		}
+	}
+	return rules, nil
+}
+
+// Award returns the change to a player's score for a finished game and a
+// message describing any streak bonus. score is the player's current score
+// and streak their win streak including this game.
+func (r *Rules) Award(score int, outcome result.Outcome, mode result.Mode, difficulty string, streak int) (int, string) {
+	base := r.TwoPlayer
+	if mode == result.ModeAI {
+		var ok bool
+		if base, ok = r.AI[difficulty]; !ok {
+			base = r.DefaultAI
+		}
+	}
+	points := 0
+	bonusMsg := ""
+	switch outcome {
+	case result.Win:
+		points = base.Win
+		for _, bonus := range r.StreakBonuses {
+			if bonus.Streak == streak {
+				points += bonus.Points
+				bonusMsg = fmt.Sprintf("You earned %d bonus points for a %d-game win streak!", bonus.Points, bonus.Streak)
+			}
+		}
+	case result.Draw:
+		points = base.Draw
+	case result.Loss:
+		points = base.Loss
+	}
+	if r.FloorAtZero && score+points < 0 {
+		points = -score
+	}
+	return points, bonusMsg
+}
+
+// AIRating returns the fixed rating of an AI difficulty or engine.
+func (r *Rules) AIRating(level string) int {
+	if rating, ok := r.AIRatings[level]; ok {
+		return rating
+	}
+	return r.DefaultAIRating
+}
+
+// RatingChange returns the Elo adjustment for a player rated rating who
+// finished a game against an opponent rated opponent with outcome.
+func (r *Rules) RatingChange(rating, opponent int, outcome result.Outcome) int {
+	expected := 1 / (1 + math.Pow(10, float64(opponent-rating)/400))
+	actual := 0.0
+	switch outcome {
+	case result.Win:
+		actual = 1
+	case result.Draw:
+		actual = 0.5
+	}
+	return int(math.Round(float64(r.BotKFactor) * (actual - expected)))
+}
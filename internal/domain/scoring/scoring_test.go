@@ -0,0 +1,114 @@
+package scoring
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tic-tac-toe/internal/domain/result"
+)
+
+func TestAward(t *testing.T) {
+	rules := Default()
+	rules.AI["hard"] = OutcomePoints{Win: 3, Draw: 1, Loss: -1}
+	rules.DefaultAI = OutcomePoints{Win: 2, Draw: 1, Loss: -2}
+	rules.FloorAtZero = false
+	tests := []struct {
+		name       string
+		outcome    result.Outcome
+		mode       result.Mode
+		difficulty string
+		want       int
+	}{
+		{"listed level win", result.Win, result.ModeAI, "hard", 3},
+		{"listed level loss", result.Loss, result.ModeAI, "hard", -1},
+		{"unknown engine win", result.Win, result.ModeAI, "stockfish", 2},
+		{"unknown engine draw", result.Draw, result.ModeAI, "stockfish", 1},
+		{"unknown engine loss", result.Loss, result.ModeAI, "stockfish", -2},
+		{"two-player ignores difficulty", result.Win, result.ModeTwoPlayer, "stockfish", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _ := rules.Award(10, tt.outcome, tt.mode, tt.difficulty, 1); got != tt.want {
+				t.Errorf("Award = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAIRating(t *testing.T) {
+	rules := Default()
+	if got := rules.AIRating("hard"); got != 1800 {
+		t.Errorf("AIRating(hard) = %d, want 1800", got)
+	}
+	if got := rules.AIRating("stockfish"); got != InitialRating {
+		t.Errorf("AIRating(stockfish) = %d, want %d", got, InitialRating)
+	}
+	rules.DefaultAIRating = 1300
+	if got := rules.AIRating("stockfish"); got != 1300 {
+		t.Errorf("AIRating(stockfish) = %d, want 1300", got)
+	}
+}
+
+func TestLoadRejectsBadRules(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want string
+	}{
+		{"not JSON", `{"two_player":`, "parse"},
+		{"wrong type", `{"two_player": {"win": "two"}}`, "parse"},
+		{"wrong type in AI level", `{"ai": {"hard": {"win": "three"}}}`, "parse"},
+		{"zero K-factor", `{"bot_k_factor": 0}`, "bot_k_factor"},
+		{"zero default rating", `{"default_ai_rating": 0}`, "default_ai_rating"},
+		{"zero streak", `{"streak_bonuses": [{"streak": 0, "points": 5}]}`, "streak"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := Load(writeRules(t, tt.json))
+			if err == nil {
+				t.Fatalf("Load = %+v, want an error", rules)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load error %q does not mention %q", err, tt.want)
+			}
+		})
+	}
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Load of a missing file succeeded")
+	}
+}
+
+func TestLoadKeepsDefaults(t *testing.T) {
+	rules, err := Load(writeRules(t, `{"ai": {"hard": {"draw": 1}, "stockfish": {"loss": -1}}, "default_ai": {"win": 4}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		level string
+		want  OutcomePoints
+	}{
+		{"easy", OutcomePoints{Win: 1}},
+		{"hard", OutcomePoints{Win: 1, Draw: 1}},
+		{"stockfish", OutcomePoints{Win: 4, Loss: -1}},
+	}
+	for _, tt := range tests {
+		if got := rules.AI[tt.level]; got != tt.want {
+			t.Errorf("AI[%s] = %+v, want %+v", tt.level, got, tt.want)
+		}
+	}
+	if rules.TwoPlayer != Default().TwoPlayer || rules.BotKFactor != Default().BotKFactor {
+		t.Errorf("settings missing from the file changed: %+v", rules)
+	}
+}
+
+// writeRules writes a rules file for Load and returns its path.
+func writeRules(t *testing.T, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "scoring.json")
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
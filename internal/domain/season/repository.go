@@ -0,0 +1,7 @@
+package season
+
+type SeasonRepository interface {
+	Save(season *Season) error
+	FindByNumber(number int) (*Season, error)
+	All() ([]*Season, error)
+}
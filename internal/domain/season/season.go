@@ -0,0 +1,27 @@
+package season
+
+import "time"
+
+// Standing is a player's final position in an archived season.
+type Standing struct {
+	Username  string
+	Score     int
+	WinStreak int
+}
+
+// Season is a completed competitive season with its final standings,
+// ordered from first place down.
+type Season struct {
+	Number    int
+	StartedAt time.Time
+	EndedAt   time.Time
+	Standings []Standing
+}
+
+// Champion returns the winner of the season, or "" if nobody scored.
+func (s *Season) Champion() string {
+	if len(s.Standings) == 0 || s.Standings[0].Score <= 0 {
+		return ""
+	}
+	return s.Standings[0].Username
+}
@@ -1,39 +1,234 @@
 package user
 
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Record tallies wins, losses and draws.
+type Record struct {
+	Wins   int
+	Losses int
+	Draws  int
+}
+
+func (r Record) Games() int {
+	return r.Wins + r.Losses + r.Draws
+}
+
+// GameResult describes a finished game from one player's point of view.
+type GameResult struct {
+	Opponent string
+	IsAIGame bool
+	// BotGame is set when a bot took part. Such games are rated separately
+	// and do not count toward the win streak.
+	BotGame bool
+	// Assisted is set when the player used hints. A win or draw in such a
+	// game earns no points and a win does not extend the win streak.
+	Assisted bool
+	Symbol   string
+	Moves    int
+}
+
+type Role string
+
+const (
+	RolePlayer Role = "player"
+	RoleAdmin  Role = "admin"
+)
+
 type User struct {
-	Username  string
-	Score     int
-	WinStreak int
+	Username        string
+	Role            Role
+	Score           int
+	WinStreak       int
+	LongestStreak   int
+	AIRecord        Record
+	TwoPlayerRecord Record
+	GamesAsX        int
+	GamesAsO        int
+	TotalMoves      int
+	HeadToHead      map[string]*Record
+	ChampionSeasons []int
+	Achievements    map[string]time.Time
+	// SSHKeys holds the SHA256 fingerprints of the public keys allowed to
+	// log in as this user over SSH. The first key used claims the username.
+	SSHKeys []string
+	// PasswordHash is the bcrypt hash of the password that logs in as this
+	// user, empty if none was set.
+	PasswordHash string
+	// IsBot marks an account played by a program. Bots log in with a token
+	// whose SHA-256 hash is kept in BotTokenHash.
+	IsBot        bool
+	BotTokenHash string
+	// BotRecord and BotRating cover the games a bot took part in, which
+	// earn an Elo rating instead of points. BotRating is 0 until the first
+	// such game.
+	BotRecord Record
+	BotRating int
 }
 
 func NewUser(username string) *User {
 	return &User{
-		Username: username,
+		Username:     username,
+		Role:         RolePlayer,
+		HeadToHead:   make(map[string]*Record),
+		Achievements: make(map[string]time.Time),
 	}
 }
 
-func (u *User) WinGame(isAIGame bool) string {
-	if isAIGame {
-		u.Score += 1
-	} else {
-		u.Score += 2
+// WinGame records a win and extends the win streak. Points are awarded
+// separately through AddPoints.
+func (u *User) WinGame(r GameResult) {
+	u.record(r, func(rec *Record) { rec.Wins++ })
+	if r.BotGame || r.Assisted {
+		return
 	}
 	u.WinStreak++
-	bonusMsg := ""
-	if u.WinStreak == 3 {
-		u.Score += 5
-		bonusMsg = "You earned 5 bonus points for a 3-game win streak!"
-	} else if u.WinStreak == 5 {
-		u.Score += 10
-		bonusMsg = "You earned 10 bonus points for a 5-game win streak!"
+	if u.WinStreak > u.LongestStreak {
+		u.LongestStreak = u.WinStreak
+	}
+}
+
+func (u *User) LoseGame(r GameResult) {
+	u.record(r, func(rec *Record) { rec.Losses++ })
+	if !r.BotGame {
+		u.WinStreak = 0
 	}
-	return bonusMsg
 }
 
-func (u *User) LoseGame() {
-	u.WinStreak = 0
+func (u *User) DrawGame(r GameResult) {
+	u.record(r, func(rec *Record) { rec.Draws++ })
+	if !r.BotGame {
+		u.WinStreak = 0
+	}
+}
+
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleAdmin
+}
+
+func (u *User) AddPoints(points int) {
+	u.Score += points
+}
+
+func (u *User) HasAchievement(id string) bool {
+	_, ok := u.Achievements[id]
+	return ok
 }
 
-func (u *User) DrawGame() {
-	u.WinStreak = 0
+// UnlockAchievement records when the achievement was earned.
+func (u *User) UnlockAchievement(id string) {
+	if u.Achievements == nil {
+		u.Achievements = make(map[string]time.Time)
+	}
+	u.Achievements[id] = time.Now()
+}
+
+// HasSSHKey reports whether fingerprint may log in as this user.
+func (u *User) HasSSHKey(fingerprint string) bool {
+	for _, key := range u.SSHKeys {
+		if key == fingerprint {
+			return true
+		}
+	}
+	return false
+}
+
+func (u *User) AddSSHKey(fingerprint string) {
+	if !u.HasSSHKey(fingerprint) {
+		u.SSHKeys = append(u.SSHKeys, fingerprint)
+	}
+}
+
+// SetBotToken makes the user a bot that logs in with token, replacing any
+// previous token.
+func (u *User) SetBotToken(token string) {
+	u.IsBot = true
+	u.BotTokenHash = hashToken(token)
+}
+
+// CheckBotToken reports whether token logs in as this bot.
+func (u *User) CheckBotToken(token string) bool {
+	if !u.IsBot || u.BotTokenHash == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(hashToken(token)), []byte(u.BotTokenHash)) == 1
+}
+
+// SetPassword makes password log in as this user, replacing any previous
+// password.
+func (u *User) SetPassword(password string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	u.PasswordHash = string(hash)
+	return nil
+}
+
+// CheckPassword reports whether password logs in as this user.
+func (u *User) CheckPassword(password string) bool {
+	if u.PasswordHash == "" {
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)) == nil
+}
+
+// Protected reports whether logging in as the user takes a credential: a
+// password, a registered SSH key or a bot token. An account without one is
+// a guest's, and is not handed to the next client using the name.
+func (u *User) Protected() bool {
+	return u.PasswordHash != "" || len(u.SSHKeys) > 0 || u.IsBot
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// GamesPlayed returns the number of finished games across all modes.
+func (u *User) GamesPlayed() int {
+	return u.AIRecord.Games() + u.TwoPlayerRecord.Games() + u.BotRecord.Games()
+}
+
+// AverageGameLength returns the mean number of moves per finished game.
+func (u *User) AverageGameLength() float64 {
+	games := u.GamesPlayed()
+	if games == 0 {
+		return 0
+	}
+	return float64(u.TotalMoves) / float64(games)
+}
+
+// record updates the counters shared by every outcome and applies tally to
+// both the per-mode and the head-to-head record.
+func (u *User) record(r GameResult, tally func(*Record)) {
+	switch {
+	case r.BotGame:
+		tally(&u.BotRecord)
+	case r.IsAIGame:
+		tally(&u.AIRecord)
+	default:
+		tally(&u.TwoPlayerRecord)
+	}
+	if u.HeadToHead == nil {
+		u.HeadToHead = make(map[string]*Record)
+	}
+	h2h, ok := u.HeadToHead[r.Opponent]
+	if !ok {
+		h2h = &Record{}
+		u.HeadToHead[r.Opponent] = h2h
+	}
+	tally(h2h)
+	if r.Symbol == "O" {
+		u.GamesAsO++
+	} else {
+		u.GamesAsX++
+	}
+	u.TotalMoves += r.Moves
 }
@@ -0,0 +1,246 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"tic-tac-toe/internal/application"
+	"tic-tac-toe/internal/domain/ai"
+	"tic-tac-toe/internal/types"
+)
+
+var adminHandlers = map[string]CommandHandler{
+	"kick":        AdminKickHandler,
+	"ban":         AdminBanHandler,
+	"unban":       AdminUnbanHandler,
+	"broadcast":   AdminBroadcastHandler,
+	"endgame":     AdminEndGameHandler,
+	"reset-score": AdminResetScoreHandler,
+	"season":      AdminSeasonHandler,
+	"audit":       AdminAuditHandler,
+	"bot":         AdminBotHandler,
+	"menace":      AdminMenaceHandler,
+}
+
+const adminUsage = "usage: admin <kick <user>|ban <user|ip>|unban <user|ip>|broadcast <msg>|endgame <id>|reset-score <user>|season start|audit <user|game> <name|id> [limit]|bot <name>|menace [reset|<board>]>"
+
+// AdminHandler checks that the player is an admin and dispatches to the
+// admin subcommand.
+func AdminHandler(player *types.Player, args []string, gameService *application.GameService, leaderboard *application.LeaderboardService, matchmaking *application.MatchmakingService, server types.Server) error {
+	if !server.IsAdmin(player.Username) {
+		return errPermissionDenied
+	}
+	if len(args) < 1 {
+		return errors.New(adminUsage)
+	}
+	handler, ok := adminHandlers[args[0]]
+	if !ok {
+		return errors.New(adminUsage)
+	}
+	return handler(player, args[1:], gameService, leaderboard, matchmaking, server)
+}
+
+func AdminKickHandler(player *types.Player, args []string, _ *application.GameService, _ *application.LeaderboardService, _ *application.MatchmakingService, server types.Server) error {
+	if len(args) < 1 {
+		return errors.New("username required")
+	}
+	if err := server.Kick(args[0], "You have been kicked by an admin."); err != nil {
+		return err
+	}
+	types.SendMessage(player, "Kicked "+args[0])
+	return nil
+}
+
+func AdminBanHandler(player *types.Player, args []string, _ *application.GameService, _ *application.LeaderboardService, _ *application.MatchmakingService, server types.Server) error {
+	if len(args) < 1 {
+		return errors.New("username or IP address required")
+	}
+	if err := server.Ban(args[0], player.Username); err != nil {
+		return err
+	}
+	types.SendMessage(player, "Banned "+args[0])
+	return nil
+}
+
+func AdminUnbanHandler(player *types.Player, args []string, _ *application.GameService, _ *application.LeaderboardService, _ *application.MatchmakingService, server types.Server) error {
+	if len(args) < 1 {
+		return errors.New("username or IP address required")
+	}
+	if err := server.Unban(args[0]); err != nil {
+		return err
+	}
+	types.SendMessage(player, "Unbanned "+args[0])
+	return nil
+}
+
+func AdminBroadcastHandler(player *types.Player, args []string, _ *application.GameService, _ *application.LeaderboardService, _ *application.MatchmakingService, server types.Server) error {
+	message := strings.TrimSpace(strings.Join(args, " "))
+	if message == "" {
+		return errors.New("message required")
+	}
+	server.Broadcast("[Admin] " + message)
+	return nil
+}
+
+func AdminEndGameHandler(player *types.Player, args []string, gameService *application.GameService, _ *application.LeaderboardService, _ *application.MatchmakingService, server types.Server) error {
+	if len(args) < 1 {
+		return errors.New("game ID required")
+	}
+	if _, err := gameService.FindGameByID(args[0]); err != nil {
+		return err
+	}
+	server.EndGame(args[0], "This game was ended by an admin. You can start a new game.")
+	types.SendMessage(player, "Ended "+args[0])
+	return nil
+}
+
+func AdminResetScoreHandler(player *types.Player, args []string, _ *application.GameService, leaderboard *application.LeaderboardService, _ *application.MatchmakingService, server types.Server) error {
+	if len(args) < 1 {
+		return errors.New("username required")
+	}
+	if err := leaderboard.ResetScore(args[0]); err != nil {
+		return err
+	}
+	types.SendMessage(player, "Reset score of "+args[0])
+	return nil
+}
+
+func AdminSeasonHandler(player *types.Player, args []string, _ *application.GameService, _ *application.LeaderboardService, _ *application.MatchmakingService, server types.Server) error {
+	if len(args) < 1 || args[0] != "start" {
+		return errors.New("usage: admin season start")
+	}
+	return server.StartSeason()
+}
+
+func AdminAuditHandler(player *types.Player, args []string, _ *application.GameService, _ *application.LeaderboardService, _ *application.MatchmakingService, server types.Server) error {
+	if len(args) < 2 {
+		return errors.New("usage: admin audit <user|game> <name|id> [limit]")
+	}
+	limit := 20
+	if len(args) > 2 {
+		n, err := strconv.Atoi(args[2])
+		if err != nil || n < 1 {
+			return errors.New("invalid limit")
+		}
+		limit = n
+	}
+	var username, gameID string
+	switch args[0] {
+	case "user":
+		username = args[1]
+	case "game":
+		gameID = args[1]
+	default:
+		return errors.New("usage: admin audit <user|game> <name|id> [limit]")
+	}
+	lines, err := server.QueryAudit(username, gameID, limit)
+	if err != nil {
+		return err
+	}
+	if len(lines) == 0 {
+		types.SendMessage(player, "No audit entries found.")
+		return nil
+	}
+	types.SendMessage(player, strings.Join(lines, "\n"))
+	return nil
+}
+
+// AdminBotHandler creates a bot account, or issues a new token for an
+// existing bot, and shows the token. It is not stored in plain text, so it
+// cannot be shown again later.
+func AdminBotHandler(player *types.Player, args []string, _ *application.GameService, _ *application.LeaderboardService, _ *application.MatchmakingService, server types.Server) error {
+	if len(args) < 1 || args[0] == "" {
+		return errors.New("usage: admin bot <name>")
+	}
+	token, err := server.RegisterBot(args[0])
+	if err != nil {
+		return err
+	}
+	types.SendMessage(player, "Bot "+args[0]+" can now log in with: bot "+args[0]+" "+token)
+	return nil
+}
+
+// AdminMenaceHandler shows what the learning menace AI has learned: its
+// record and the beads in its box for the empty board, or for the position
+// given as nine cells of X, O or -, row by row. "reset" makes it forget
+// everything.
+func AdminMenaceHandler(player *types.Player, args []string, gameService *application.GameService, _ *application.LeaderboardService, _ *application.MatchmakingService, server types.Server) error {
+	engine, _ := gameService.Engine("menace")
+	menace, ok := engine.(*ai.Menace)
+	if !ok {
+		return errors.New("the menace AI is not running")
+	}
+	board := [9]string{" ", " ", " ", " ", " ", " ", " ", " ", " "}
+	if len(args) > 0 {
+		if args[0] == "reset" {
+			if err := menace.Reset(); err != nil {
+				return err
+			}
+			types.SendMessage(player, "MENACE has forgotten everything it learned.")
+			return nil
+		}
+		var err error
+		if board, err = parseMenaceBoard(args[0]); err != nil {
+			return err
+		}
+	}
+	memory := menace.Memory()
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "MENACE has learned from %d games (%d wins, %d draws, %d losses) and has %d boxes.\n",
+		memory.Games(), memory.Wins, memory.Draws, memory.Losses, len(memory.Boxes))
+	beads, ok := menace.Beads(board)
+	if !ok {
+		sb.WriteString("It has not moved in this position yet.")
+		types.SendMessage(player, sb.String())
+		return nil
+	}
+	sb.WriteString("Beads for each cell of this position:\n")
+	for row := 0; row < 3; row++ {
+		if row > 0 {
+			sb.WriteString("-----+-----+-----\n")
+		}
+		cells := make([]string, 3)
+		for col := range cells {
+			i := row*3 + col
+			cells[col] = fmt.Sprintf("%4d ", beads[i])
+			if board[i] != " " {
+				cells[col] = "  " + board[i] + "  "
+			}
+		}
+		sb.WriteString(strings.Join(cells, "|") + "\n")
+	}
+	types.SendMessage(player, strings.TrimSuffix(sb.String(), "\n"))
+	return nil
+}
+
+const menaceUsage = "usage: admin menace [reset|<board>], where board is nine cells of X, O or -"
+
+// parseMenaceBoard reads a position written as nine cells of X, O or -,
+// row by row, in which X has moved first.
+func parseMenaceBoard(s string) ([9]string, error) {
+	var board [9]string
+	s = strings.ToUpper(s)
+	if len(s) != len(board) {
+		return board, errors.New(menaceUsage)
+	}
+	xCount, oCount := 0, 0
+	for i, c := range s {
+		switch c {
+		case 'X':
+			xCount++
+			board[i] = "X"
+		case 'O':
+			oCount++
+			board[i] = "O"
+		case '-':
+			board[i] = " "
+		default:
+			return board, errors.New(menaceUsage)
+		}
+	}
+	if xCount != oCount && xCount != oCount+1 {
+		return board, errors.New("impossible position: X moves first and the players take turns")
+	}
+	return board, nil
+}
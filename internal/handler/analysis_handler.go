@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"tic-tac-toe/internal/application"
+	"tic-tac-toe/internal/domain/ai"
+	"tic-tac-toe/internal/types"
+)
+
+// assistedNotice tells the player, on their first hint of a game, what it
+// costs them.
+const assistedNotice = "Hints are on for this game, so a win or draw will earn no points.\n"
+
+// HintHandler suggests the best move in the player's AI game.
+func HintHandler(player *types.Player, args []string, gameService *application.GameService, _ *application.LeaderboardService, _ *application.MatchmakingService, server types.Server) error {
+	if player.GameID == "" {
+		return errors.New("not in a game")
+	}
+	move, first, err := gameService.Hint(player.GameID, player.Username)
+	if err != nil {
+		return err
+	}
+	message := fmt.Sprintf("Hint: move %d, %s.", move.Position+1, describeMove(move))
+	if first {
+		message = assistedNotice + message
+	}
+	types.SendMessage(player, message)
+	return nil
+}
+
+// AnalyzeHandler labels every empty cell of the player's AI game as a win,
+// draw or loss with perfect play.
+func AnalyzeHandler(player *types.Player, args []string, gameService *application.GameService, _ *application.LeaderboardService, _ *application.MatchmakingService, server types.Server) error {
+	if player.GameID == "" {
+		return errors.New("not in a game")
+	}
+	values, first, err := gameService.Analyze(player.GameID, player.Username)
+	if err != nil {
+		return err
+	}
+	g, err := gameService.FindGameByID(player.GameID)
+	if err != nil {
+		return err
+	}
+	cells := g.Board
+	for _, v := range values {
+		cells[v.Position] = strings.ToUpper(v.Outcome.String()[:1])
+	}
+	var sb strings.Builder
+	if first {
+		sb.WriteString(assistedNotice)
+	}
+	sb.WriteString("Analysis (W win, D draw, L loss with perfect play):\n")
+	for row := 0; row < 3; row++ {
+		fmt.Fprintf(&sb, "%s | %s | %s\n", cells[row*3], cells[row*3+1], cells[row*3+2])
+		if row < 2 {
+			sb.WriteString("-----------\n")
+		}
+	}
+	for _, v := range values {
+		fmt.Fprintf(&sb, "\nMove %d: %s", v.Position+1, describeMove(v))
+	}
+	types.SendMessage(player, sb.String())
+	return nil
+}
+
+// describeMove says how a move ends with perfect play, counting a win in
+// the player's own moves.
+func describeMove(v ai.MoveValue) string {
+	own := (v.Moves + 1) / 2
+	switch v.Outcome {
+	case ai.Win:
+		if own == 1 {
+			return "which wins at once"
+		}
+		return fmt.Sprintf("which wins in %d of your moves", own)
+	case ai.Loss:
+		return "which loses against perfect play"
+	}
+	return "which draws"
+}
+
+// ReviewHandler goes over the player's last game move by move, grading each
+// move against perfect play and showing the better move for mistakes.
+func ReviewHandler(player *types.Player, args []string, gameService *application.GameService, _ *application.LeaderboardService, _ *application.MatchmakingService, server types.Server) error {
+	g, reviews, err := gameService.Review(player.Username)
+	if err != nil {
+		return err
+	}
+	outcome := "unfinished"
+	switch {
+	case g.Winner == player.Username:
+		outcome = "you won"
+	case g.Winner != "":
+		outcome = g.Winner + " won"
+	case g.IsDraw:
+		outcome = "a draw"
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Review of your game as %s against %s (%s):", g.SymbolOf(player.Username), g.Opponent(player.Username), outcome)
+	counts := make(map[ai.Verdict]int)
+	for i, r := range reviews {
+		fmt.Fprintf(&sb, "\n%d. %s %s: move %d, %s", i+1, r.Symbol, r.Move.Player, r.Move.Position+1, r.Verdict)
+		switch r.Verdict {
+		case ai.Blunder:
+			fmt.Fprintf(&sb, ", turning a %s into a %s; move %d was best, %s", r.Best.Outcome, r.Played.Outcome, r.Best.Position+1, describeMove(r.Best))
+		case ai.Inaccuracy:
+			fmt.Fprintf(&sb, "; move %d was better, %s", r.Best.Position+1, describeMove(r.Best))
+		}
+		if r.Move.Player == player.Username {
+			counts[r.Verdict]++
+		}
+	}
+	fmt.Fprintf(&sb, "\nYour moves: best %d, inaccuracies %d, blunders %d.", counts[ai.Best], counts[ai.Inaccuracy], counts[ai.Blunder])
+	types.SendMessage(player, sb.String())
+	return nil
+}
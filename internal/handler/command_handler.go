@@ -2,31 +2,101 @@ package handler
 
 import (
 	"errors"
+	"math/rand"
 	"strconv"
+	"strings"
 	"tic-tac-toe/internal/application"
+	"tic-tac-toe/internal/domain/ai"
+	"tic-tac-toe/internal/domain/game"
+	"tic-tac-toe/internal/domain/result"
+	"tic-tac-toe/internal/render"
 	"tic-tac-toe/internal/types"
+	"tic-tac-toe/pkg/protocol"
 	"time"
+	"unicode"
 )
 
 var ErrExit = errors.New("exit requested")
 
+var (
+	errUnknownCommand   = errors.New("unknown command")
+	errPermissionDenied = errors.New("permission denied")
+)
+
 type CommandHandler func(player *types.Player, args []string, gameService *application.GameService, leaderboard *application.LeaderboardService, matchmaking *application.MatchmakingService, server types.Server) error
 
 var handlers = map[string]CommandHandler{
-	"join":        JoinGameHandler,
-	"move":        MakeMoveHandler,
-	"leaderboard": LeaderboardHandler,
-	"exit":        ExitHandler,
+	"join":         JoinGameHandler,
+	"challenge":    ChallengeHandler,
+	"accept":       AcceptHandler,
+	"decline":      DeclineHandler,
+	"exhibition":   ExhibitionHandler,
+	"spectate":     SpectateHandler,
+	"move":         MakeMoveHandler,
+	"hint":         HintHandler,
+	"analyze":      AnalyzeHandler,
+	"review":       ReviewHandler,
+	"leaderboard":  LeaderboardHandler,
+	"rank":         RankHandler,
+	"profile":      ProfileHandler,
+	"achievements": AchievementsHandler,
+	"display":      DisplayHandler,
+	"say":          SayHandler,
+	"lobby":        LobbyHandler,
+	"protocol":     ProtocolHandler,
+	"admin":        AdminHandler,
+	"ping":         PingHandler,
+	"password":     PasswordHandler,
+	"exit":         ExitHandler,
 }
 
 func HandleCommand(player *types.Player, command string, args []string, gameService *application.GameService, leaderboard *application.LeaderboardService, matchmaking *application.MatchmakingService, server types.Server) error {
 	handler, ok := handlers[command]
 	if !ok {
-		return errors.New("unknown command")
+		return errUnknownCommand
 	}
 	return handler(player, args, gameService, leaderboard, matchmaking, server)
 }
 
+// MetricsLabel returns command if it is a known command and "unknown"
+// otherwise, so arbitrary input cannot create new metric series.
+func MetricsLabel(command string) string {
+	if _, ok := handlers[command]; ok {
+		return command
+	}
+	return "unknown"
+}
+
+// ErrorCategory sorts a command error into one of a fixed set of
+// categories for metrics: unknown_command, forbidden, not_your_turn,
+// game_over, invalid_move, invalid_args, not_found, conflict or other.
+// Error messages often echo user input, so they cannot be labels
+// themselves.
+func ErrorCategory(err error) string {
+	switch {
+	case errors.Is(err, errUnknownCommand):
+		return "unknown_command"
+	case errors.Is(err, errPermissionDenied):
+		return "forbidden"
+	case errors.Is(err, game.ErrNotYourTurn):
+		return "not_your_turn"
+	case errors.Is(err, game.ErrGameOver):
+		return "game_over"
+	case errors.Is(err, game.ErrInvalidPosition), errors.Is(err, game.ErrCellTaken):
+		return "invalid_move"
+	}
+	message := err.Error()
+	switch {
+	case strings.HasPrefix(message, "usage:"), strings.Contains(message, "required"), strings.Contains(message, "invalid"):
+		return "invalid_args"
+	case strings.Contains(message, "not found"), strings.Contains(message, "not online"), strings.HasPrefix(message, "not "), strings.HasPrefix(message, "no "):
+		return "not_found"
+	case strings.Contains(message, "already"), strings.Contains(message, "finish your current game"), strings.Contains(message, "is in a game"):
+		return "conflict"
+	}
+	return "other"
+}
+
 func JoinGameHandler(player *types.Player, args []string, gameService *application.GameService, _ *application.LeaderboardService, matchmaking *application.MatchmakingService, server types.Server) error {
 	if len(args) < 1 {
 		return errors.New("mode required: two-player or ai")
@@ -41,37 +111,160 @@ func JoinGameHandler(player *types.Player, args []string, gameService *applicati
 		}
 		if gameID == "" {
 			types.SendMessage(player, "Waiting for an opponent...")
+			server.BroadcastLobby()
 			return nil
 		}
-
-		// Game has been created, add both players to the game
+		return startTwoPlayerGame(gameID, gameService, server)
+	} else if mode == "ai" {
+		levelName, symbol, err := parseJoinAI(args[1:])
+		if err != nil {
+			return err
+		}
+		level, ok := gameService.ParseAILevel(levelName)
+		if !ok {
+			return errors.New("invalid difficulty: " + strings.Join(aiLevels(gameService), ", "))
+		}
+		gameID, opening, err := gameService.StartAIGame(player.Username, level, symbol)
+		if err != nil {
+			return err
+		}
 		g, err := gameService.FindGameByID(gameID)
 		if err != nil {
 			return err
 		}
+		if err := server.AddPlayersToGame(gameID, player); err != nil {
+			gameService.DeleteGame(gameID)
+			return err
+		}
+		if opening == "" {
+			showGame(player, g, server.TurnTimeout(), "Game started. Your turn.\n", "")
+		} else {
+			showGame(player, g, server.TurnTimeout(), "Game started. You are O.\n", "\n"+opening+"\nYour turn.")
+		}
+	} else {
+		return errors.New("invalid mode")
+	}
+	return nil
+}
 
-		for _, username := range g.Players {
-			p := server.GetPlayer(username)
-			if p != nil {
-				p.GameID = gameID
-				server.AddPlayerToGame(gameID, p)
+// parseJoinAI reads the arguments of "join ai": an optional level and an
+// optional "--as X|O|random" choosing the player's mark, X by default.
+func parseJoinAI(args []string) (level, symbol string, err error) {
+	symbol = "X"
+	for i := 0; i < len(args); i++ {
+		if args[i] != "--as" {
+			if level != "" {
+				return "", "", errors.New("usage: join ai [level] [--as X|O|random]")
 			}
+			level = args[i]
+			continue
+		}
+		if i+1 == len(args) {
+			return "", "", errors.New("usage: join ai [level] [--as X|O|random]")
 		}
+		i++
+		switch strings.ToUpper(args[i]) {
+		case "X", "O":
+			symbol = strings.ToUpper(args[i])
+		case "RANDOM":
+			symbol = []string{"X", "O"}[rand.Intn(2)]
+		default:
+			return "", "", errors.New("--as takes X, O or random")
+		}
+	}
+	return level, symbol, nil
+}
 
-		server.BroadcastToGame(gameID, "Game started. "+gameService.GetCurrentTurn(gameID)+"'s turn.")
-		server.BroadcastToGame(gameID, gameService.GetBoard(gameID))
-	} else if mode == "ai" {
-		gameID, err = gameService.StartAIGame(player.Username)
-		if err != nil {
-			return err
+// aiLevels lists the difficulties and engines accepted by "join ai".
+func aiLevels(gameService *application.GameService) []string {
+	return append([]string{string(ai.Easy), string(ai.Medium), string(ai.Hard)}, gameService.EngineNames()...)
+}
+
+// startTwoPlayerGame adds both players of a newly created game to it and
+// shows them the board. If either has started another game since the game
+// was created, it is dropped instead.
+func startTwoPlayerGame(gameID string, gameService *application.GameService, server types.Server) error {
+	g, err := gameService.FindGameByID(gameID)
+	if err != nil {
+		return err
+	}
+	var players []*types.Player
+	for _, username := range g.Players {
+		if p := server.GetPlayer(username); p != nil {
+			players = append(players, p)
 		}
-		player.GameID = gameID
-		server.AddPlayerToGame(gameID, player)
-		types.SendMessage(player, "Game started. Your turn.")
-		types.SendMessage(player, gameService.GetBoard(gameID))
+	}
+	if err := server.AddPlayersToGame(gameID, players...); err != nil {
+		gameService.DeleteGame(gameID)
+		return err
+	}
+	broadcastGame(server, g, "Game started. "+g.CurrentTurn+"'s turn.\n", "")
+	return nil
+}
+
+// ChallengeHandler invites another player in the lobby, human or bot, to a
+// two-player game.
+func ChallengeHandler(player *types.Player, args []string, _ *application.GameService, _ *application.LeaderboardService, matchmaking *application.MatchmakingService, server types.Server) error {
+	if len(args) < 1 || args[0] == "" {
+		return errors.New("usage: challenge <username>")
+	}
+	if player.GameID != "" {
+		return errors.New("finish your current game first")
+	}
+	opponent := server.GetPlayer(args[0])
+	if opponent == nil {
+		return errors.New("player not online")
+	}
+	if opponent.GameID != "" {
+		return errors.New(opponent.Username + " is in a game")
+	}
+	if err := matchmaking.Challenge(player.Username, opponent.Username); err != nil {
+		return err
+	}
+	if opponent.JSON {
+		types.SendEvent(opponent, protocol.Event{Type: protocol.EventChallenge, From: player.Username})
 	} else {
-		return errors.New("invalid mode")
+		types.SendMessage(opponent, player.Username+" challenges you to a game. Type 'accept "+player.Username+"' or 'decline "+player.Username+"'.")
 	}
+	types.SendMessage(player, "Challenge sent to "+opponent.Username+".")
+	return nil
+}
+
+// AcceptHandler starts a game against a player who challenged you. The
+// challenger moves first.
+func AcceptHandler(player *types.Player, args []string, gameService *application.GameService, _ *application.LeaderboardService, matchmaking *application.MatchmakingService, server types.Server) error {
+	if len(args) < 1 || args[0] == "" {
+		return errors.New("usage: accept <username>")
+	}
+	if player.GameID != "" {
+		return errors.New("finish your current game first")
+	}
+	challenger := server.GetPlayer(args[0])
+	if challenger == nil {
+		matchmaking.DeclineChallenge(player.Username, args[0])
+		return errors.New("player not online")
+	}
+	if challenger.GameID != "" {
+		return errors.New(challenger.Username + " is in a game")
+	}
+	gameID, err := matchmaking.AcceptChallenge(player.Username, challenger.Username)
+	if err != nil {
+		return err
+	}
+	return startTwoPlayerGame(gameID, gameService, server)
+}
+
+func DeclineHandler(player *types.Player, args []string, _ *application.GameService, _ *application.LeaderboardService, matchmaking *application.MatchmakingService, server types.Server) error {
+	if len(args) < 1 || args[0] == "" {
+		return errors.New("usage: decline <username>")
+	}
+	if err := matchmaking.DeclineChallenge(player.Username, args[0]); err != nil {
+		return err
+	}
+	if challenger := server.GetPlayer(args[0]); challenger != nil {
+		types.SendMessage(challenger, player.Username+" declined your challenge.")
+	}
+	types.SendMessage(player, "Declined "+args[0]+"'s challenge.")
 	return nil
 }
 
@@ -86,29 +279,35 @@ func MakeMoveHandler(player *types.Player, args []string, gameService *applicati
 	if player.GameID == "" {
 		return errors.New("not in a game")
 	}
-	board, result, bonusMsg, err := gameService.MakeMove(player.GameID, player.Username, position-1)
+	g, result, bonusMsg, err := gameService.MakeMove(player.Logger(), player.GameID, player.Username, position-1)
 	if err != nil {
 		return err
 	}
-	message := "Board:\n" + board
+	footer := ""
 	if result != "" {
-		message += "\n" + result
+		footer += "\n" + result
 	}
 	if bonusMsg != "" {
-		message += "\n" + bonusMsg
+		footer += "\n" + bonusMsg
 	}
 
-	if gameService.IsAIGame(player.GameID) {
-		types.SendMessage(player, message)
-	} else {
-		server.BroadcastToGame(player.GameID, message)
+	broadcastGame(server, g, "Board:\n", footer)
+
+	// Announce achievements unlocked by this move
+	for _, username := range g.Players {
+		p := server.GetPlayer(username)
+		if p == nil {
+			continue
+		}
+		for _, announcement := range gameService.TakeAnnouncements(username) {
+			types.SendMessage(p, announcement)
+		}
 	}
 
 	// Notify next player if game continues
-	g, err := gameService.FindGameByID(player.GameID)
-	if err == nil && (g.Winner != "" || g.IsDraw) {
-		server.EndGame(player.GameID, "Game has ended. You can start a new game.")
-	} else if err == nil && !g.IsDraw {
+	if g.Over() {
+		server.EndGame(player.GameID, "Game has ended. You can start a new game, or type 'review' to go over your moves.")
+	} else {
 		// Notify next player if game continues
 		currentTurn := g.CurrentTurn
 		if currentTurn == "" {
@@ -125,7 +324,68 @@ func MakeMoveHandler(player *types.Player, args []string, gameService *applicati
 }
 
 func LeaderboardHandler(player *types.Player, args []string, _ *application.GameService, leaderboard *application.LeaderboardService, _ *application.MatchmakingService, server types.Server) error {
-	leaderboardStr, err := leaderboard.GetLeaderboard()
+	if len(args) > 0 && args[0] == "season" {
+		return seasonLeaderboard(player, args[1:], leaderboard)
+	}
+	if len(args) > 0 && args[0] == "bots" {
+		return botLeaderboard(player, args[1:], leaderboard)
+	}
+	query, err := parseLeaderboardQuery(args)
+	if err != nil {
+		return err
+	}
+	if player.JSON {
+		standings, err := leaderboard.GetStandings(query)
+		if err != nil {
+			return err
+		}
+		event := protocol.Event{Type: protocol.EventLeaderboard, Standings: []protocol.Standing{}}
+		for i, st := range standings {
+			event.Standings = append(event.Standings, protocol.Standing{
+				Rank:      i + 1,
+				Username:  st.Username,
+				Points:    st.Points,
+				Wins:      st.Wins,
+				WinStreak: st.WinStreak,
+			})
+		}
+		types.SendEvent(player, event)
+		return nil
+	}
+	leaderboardStr, err := leaderboard.GetLeaderboard(query)
+	if err != nil {
+		return err
+	}
+	types.SendMessage(player, leaderboardStr)
+	return nil
+}
+
+// botLeaderboard shows the players of bot games ranked by bot rating:
+// "leaderboard bots [top N]".
+func botLeaderboard(player *types.Player, args []string, leaderboard *application.LeaderboardService) error {
+	query, err := parseLeaderboardQuery(args)
+	if err != nil || query.Window != application.WindowAll || query.Mode != "" {
+		return errors.New("usage: leaderboard bots [top N]")
+	}
+	if player.JSON {
+		standings, err := leaderboard.GetBotStandings(query.Top)
+		if err != nil {
+			return err
+		}
+		event := protocol.Event{Type: protocol.EventLeaderboard, Standings: []protocol.Standing{}}
+		for i, st := range standings {
+			event.Standings = append(event.Standings, protocol.Standing{
+				Rank:     i + 1,
+				Username: st.Username,
+				Wins:     st.Wins,
+				Rating:   st.Rating,
+				Bot:      st.Bot,
+			})
+		}
+		types.SendEvent(player, event)
+		return nil
+	}
+	leaderboardStr, err := leaderboard.GetBotLeaderboard(query.Top)
 	if err != nil {
 		return err
 	}
@@ -133,6 +393,198 @@ func LeaderboardHandler(player *types.Player, args []string, _ *application.Game
 	return nil
 }
 
+// seasonLeaderboard shows the archived standings of season <n>, or lists the
+// archived seasons when no number is given.
+func seasonLeaderboard(player *types.Player, args []string, leaderboard *application.LeaderboardService) error {
+	if len(args) < 1 || args[0] == "" {
+		seasons, err := leaderboard.GetSeasons()
+		if err != nil {
+			return err
+		}
+		types.SendMessage(player, seasons)
+		return nil
+	}
+	number, err := strconv.Atoi(args[0])
+	if err != nil {
+		return errors.New("invalid season number")
+	}
+	standings, err := leaderboard.GetSeason(number)
+	if err != nil {
+		return err
+	}
+	types.SendMessage(player, standings)
+	return nil
+}
+
+func RankHandler(player *types.Player, args []string, _ *application.GameService, leaderboard *application.LeaderboardService, _ *application.MatchmakingService, server types.Server) error {
+	query, err := parseLeaderboardQuery(args)
+	if err != nil {
+		return err
+	}
+	rank, err := leaderboard.GetRank(player.Username, query)
+	if err != nil {
+		return err
+	}
+	types.SendMessage(player, rank)
+	return nil
+}
+
+// parseLeaderboardQuery reads "[daily|weekly|monthly|all] [ai|two-player] [top N]"
+// in any order.
+func parseLeaderboardQuery(args []string) (application.LeaderboardQuery, error) {
+	query := application.LeaderboardQuery{Window: application.WindowAll}
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; arg {
+		case "":
+			continue
+		case "daily", "weekly", "monthly", "all":
+			query.Window = application.Window(arg)
+		case "ai", "two-player":
+			query.Mode = result.Mode(arg)
+		case "top":
+			if i+1 >= len(args) {
+				return query, errors.New("top requires a number")
+			}
+			i++
+			n, err := strconv.Atoi(args[i])
+			if err != nil || n < 1 {
+				return query, errors.New("invalid top count")
+			}
+			query.Top = n
+		default:
+			return query, errors.New("usage: leaderboard [daily|weekly|monthly|all] [ai|two-player] [top N]")
+		}
+	}
+	return query, nil
+}
+
+func ProfileHandler(player *types.Player, args []string, _ *application.GameService, leaderboard *application.LeaderboardService, _ *application.MatchmakingService, server types.Server) error {
+	username := player.Username
+	if len(args) > 0 && args[0] != "" {
+		username = args[0]
+	}
+	profile, err := leaderboard.GetProfile(username)
+	if err != nil {
+		return err
+	}
+	types.SendMessage(player, profile)
+	return nil
+}
+
+func AchievementsHandler(player *types.Player, args []string, _ *application.GameService, leaderboard *application.LeaderboardService, _ *application.MatchmakingService, server types.Server) error {
+	achievements, err := leaderboard.GetAchievements(player.Username)
+	if err != nil {
+		return err
+	}
+	types.SendMessage(player, achievements)
+	return nil
+}
+
+// DisplayHandler shows or changes how boards are drawn on this connection
+// and redraws the current board in the new mode.
+func DisplayHandler(player *types.Player, args []string, gameService *application.GameService, _ *application.LeaderboardService, _ *application.MatchmakingService, server types.Server) error {
+	if len(args) < 1 || args[0] == "" {
+		types.SendMessage(player, "Display mode: "+string(player.Display)+" (plain, color or redraw)")
+		return nil
+	}
+	mode, ok := render.ParseMode(args[0])
+	if !ok {
+		return errors.New("invalid display mode: plain, color or redraw")
+	}
+	player.Display = mode
+	types.SendMessage(player, "Display mode set to "+string(mode)+".")
+	if player.GameID != "" {
+		if g, err := gameService.FindGameByID(player.GameID); err == nil {
+			showGame(player, g, server.TurnTimeout(), "Board:\n", "")
+		}
+	}
+	return nil
+}
+
+// SayHandler sends a chat line to the other players in your game, or to
+// everyone in the lobby when you are not in a game.
+func SayHandler(player *types.Player, args []string, _ *application.GameService, _ *application.LeaderboardService, _ *application.MatchmakingService, server types.Server) error {
+	text := strings.TrimSpace(stripControl(strings.Join(args, " ")))
+	if text == "" {
+		return errors.New("usage: say <message>")
+	}
+	from := stripControl(player.Username)
+	event := protocol.Event{Type: protocol.EventChat, From: from, Text: text, Scope: "lobby"}
+	send := func(p *types.Player) {
+		if p == player {
+			return
+		}
+		if p.JSON {
+			types.SendEvent(p, event)
+		} else {
+			types.SendMessage(p, "["+event.Scope+"] "+from+": "+text)
+		}
+	}
+	if player.GameID != "" {
+		event.Scope = "game"
+		server.BroadcastFunc(player.GameID, send)
+		return nil
+	}
+	for _, p := range server.GetPlayers() {
+		if p.GameID == "" {
+			send(p)
+		}
+	}
+	return nil
+}
+
+// stripControl removes control characters, such as the escape that starts
+// ANSI and OSC sequences, so chat cannot drive other players' terminals.
+func stripControl(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// LobbyHandler lists the players online and what they are doing.
+func LobbyHandler(player *types.Player, args []string, _ *application.GameService, _ *application.LeaderboardService, _ *application.MatchmakingService, server types.Server) error {
+	lobby := server.Lobby()
+	if player.JSON {
+		types.SendEvent(player, protocol.Event{Type: protocol.EventLobby, Lobby: &lobby})
+		return nil
+	}
+	message := "Players online:"
+	for _, p := range lobby.Players {
+		status := p.Status
+		if p.GameID != "" {
+			status += " " + p.GameID
+		}
+		if p.Bot {
+			status += ", bot"
+		}
+		message += "\n" + p.Username + " (" + status + ")"
+	}
+	types.SendMessage(player, message)
+	return nil
+}
+
+// PingHandler lets clients keep an idle connection alive.
+func PingHandler(player *types.Player, args []string, _ *application.GameService, _ *application.LeaderboardService, _ *application.MatchmakingService, server types.Server) error {
+	types.SendMessage(player, "pong")
+	return nil
+}
+
+// PasswordHandler sets the password that logs in as the player, keeping
+// their account and stats for the next session.
+func PasswordHandler(player *types.Player, args []string, _ *application.GameService, _ *application.LeaderboardService, _ *application.MatchmakingService, server types.Server) error {
+	if len(args) != 1 || args[0] == "" {
+		return errors.New("usage: password <new password>, without spaces")
+	}
+	if err := server.SetPassword(player.Username, args[0]); err != nil {
+		return err
+	}
+	types.SendMessage(player, "Password set. Log in with: login "+player.Username+" <password>")
+	return nil
+}
+
 func ExitHandler(player *types.Player, args []string, _ *application.GameService, _ *application.LeaderboardService, _ *application.MatchmakingService, server types.Server) error {
 	server.ExitPlayer(player)
 
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"tic-tac-toe/internal/application"
+	"tic-tac-toe/internal/types"
+	"time"
+)
+
+// ExhibitionHandler starts a game between two AIs, each a difficulty or a
+// registered engine, and makes the player its first spectator. The server
+// plays the game out one move at a time while anyone is watching.
+func ExhibitionHandler(player *types.Player, args []string, gameService *application.GameService, _ *application.LeaderboardService, _ *application.MatchmakingService, server types.Server) error {
+	if len(args) < 2 {
+		return errors.New("usage: exhibition <level> <level>, where a level is one of " + strings.Join(aiLevels(gameService), ", "))
+	}
+	if player.GameID != "" {
+		return errors.New("finish your current game first")
+	}
+	levelX, okX := gameService.ParseAILevel(args[0])
+	levelO, okO := gameService.ParseAILevel(args[1])
+	if !okX || !okO {
+		return errors.New("invalid level: " + strings.Join(aiLevels(gameService), ", "))
+	}
+	gameID, err := gameService.StartExhibition(levelX, levelO)
+	if err != nil {
+		return err
+	}
+	g, err := gameService.FindGameByID(gameID)
+	if err != nil {
+		return err
+	}
+	player.Logger().Info("exhibition started", "watching", gameID, "x", levelX, "o", levelO)
+	server.Spectate(gameID, player)
+	showGame(player, g, 0, fmt.Sprintf("Exhibition %s: %s vs %s.\n", gameID, g.Players[0], g.Players[1]), "")
+	go runExhibition(gameID, gameService, server)
+	return nil
+}
+
+// runExhibition plays an exhibition game to the end, pausing before each
+// move and showing the board to its spectators. The game is abandoned once
+// nobody is watching, and the loop stops if the game is ended elsewhere,
+// for example by an admin.
+func runExhibition(gameID string, gameService *application.GameService, server types.Server) {
+	logger := slog.With("game_id", gameID)
+	for {
+		time.Sleep(server.ExhibitionMoveDelay())
+		if _, err := gameService.FindGameByID(gameID); err != nil {
+			return
+		}
+		if server.SpectatorCount(gameID) == 0 {
+			logger.Info("exhibition abandoned: no spectators")
+			server.EndGame(gameID, "Exhibition ended: nobody is watching.")
+			return
+		}
+		g, result, err := gameService.PlayExhibitionMove(gameID)
+		if err != nil {
+			if _, findErr := gameService.FindGameByID(gameID); findErr != nil {
+				return
+			}
+			logger.Error("exhibition move failed", "error", err)
+			server.EndGame(gameID, "Exhibition ended: "+err.Error())
+			return
+		}
+		broadcastGame(server, g, "Board:\n", "\n"+result)
+		if g.Over() {
+			server.EndGame(gameID, "The exhibition has ended.")
+			return
+		}
+	}
+}
+
+// SpectateHandler lists the games in progress, starts watching one of them
+// or, with "off", stops watching.
+func SpectateHandler(player *types.Player, args []string, gameService *application.GameService, _ *application.LeaderboardService, _ *application.MatchmakingService, server types.Server) error {
+	if len(args) < 1 {
+		games := gameService.ActiveGames()
+		if len(games) == 0 {
+			types.SendMessage(player, "No games in progress. Start one between two AIs with 'exhibition <level> <level>'.")
+			return nil
+		}
+		var sb strings.Builder
+		sb.WriteString("Games in progress:\n")
+		for _, g := range games {
+			fmt.Fprintf(&sb, "%s: %s vs %s (%s)\n", g.ID, g.Players[0], g.Players[1], gameView(g, 0).Mode)
+		}
+		sb.WriteString("Type 'spectate <game-id>' to watch one.")
+		types.SendMessage(player, sb.String())
+		return nil
+	}
+	if args[0] == "off" {
+		if player.Watching == "" {
+			return errors.New("not spectating a game")
+		}
+		server.StopSpectating(player)
+		types.SendMessage(player, "Stopped spectating.")
+		return nil
+	}
+	if player.GameID != "" {
+		return errors.New("finish your current game first")
+	}
+	g, err := gameService.FindGameByID(args[0])
+	if err != nil || g.Over() {
+		return errors.New("no game in progress with that ID; type 'spectate' to list them")
+	}
+	server.Spectate(g.ID, player)
+	showGame(player, g, server.TurnTimeout(), fmt.Sprintf("Spectating %s vs %s. Type 'spectate off' to stop.\n", g.Players[0], g.Players[1]), "")
+	return nil
+}
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"errors"
+	"strings"
+	"tic-tac-toe/internal/application"
+	"tic-tac-toe/internal/domain/game"
+	"tic-tac-toe/internal/domain/result"
+	"tic-tac-toe/internal/render"
+	"tic-tac-toe/internal/types"
+	"tic-tac-toe/pkg/protocol"
+	"time"
+)
+
+// ProtocolHandler switches the connection between human-readable text and
+// the JSON lines protocol. It is also accepted at the username prompt.
+func ProtocolHandler(player *types.Player, args []string, _ *application.GameService, _ *application.LeaderboardService, _ *application.MatchmakingService, server types.Server) error {
+	if len(args) < 1 {
+		return errors.New("usage: protocol <text|json>")
+	}
+	switch args[0] {
+	case "json":
+		player.JSON = true
+		types.SendEvent(player, protocol.Event{
+			Type:          protocol.EventHello,
+			Version:       protocol.Version,
+			TurnTimeoutMS: server.TurnTimeout().Milliseconds(),
+		})
+		if player.Username != "" {
+			types.SendEvent(player, protocol.Event{Type: protocol.EventLogin, Username: player.Username})
+			lobby := server.Lobby()
+			types.SendEvent(player, protocol.Event{Type: protocol.EventLobby, Lobby: &lobby})
+		}
+	case "text":
+		player.JSON = false
+		types.SendMessage(player, "Protocol set to text.")
+	default:
+		return errors.New("usage: protocol <text|json>")
+	}
+	return nil
+}
+
+// broadcastGame shows g to everyone in it and everyone watching it; see
+// showGame.
+func broadcastGame(server types.Server, g *game.Game, header, footer string) {
+	turnTimeout := server.TurnTimeout()
+	server.BroadcastFunc(g.ID, func(p *types.Player) {
+		showGame(p, g, turnTimeout, header, footer)
+	})
+}
+
+// showGame sends g to the player: text clients get the board rendered in
+// their display mode between header and footer, JSON protocol clients get
+// a game event followed by the footer as a message. The header only
+// restates what the game event carries.
+func showGame(p *types.Player, g *game.Game, turnTimeout time.Duration, header, footer string) {
+	if !p.JSON {
+		types.SendMessage(p, render.Frame(g, p.Display, p.TerminalWidth(), header, footer))
+		return
+	}
+	types.SendEvent(p, protocol.Event{Type: protocol.EventGame, Game: gameView(g, turnTimeout)})
+	if footer = strings.TrimPrefix(footer, "\n"); footer != "" {
+		types.SendMessage(p, footer)
+	}
+}
+
+// gameView converts g to its protocol form.
+func gameView(g *game.Game, turnTimeout time.Duration) *protocol.Game {
+	view := &protocol.Game{
+		ID:       g.ID,
+		Mode:     string(result.ModeTwoPlayer),
+		Players:  g.Players,
+		Board:    g.Board,
+		LastMove: g.LastMove + 1,
+		Winner:   g.Winner,
+		Draw:     g.IsDraw,
+	}
+	if g.IsExhibition() {
+		view.Mode = application.ModeExhibition
+	} else if g.IsAIGame {
+		view.Mode = string(result.ModeAI)
+	}
+	for _, position := range g.WinningLine() {
+		view.WinningLine = append(view.WinningLine, position+1)
+	}
+	if !view.Over() {
+		view.CurrentTurn = g.CurrentTurn
+		if turnTimeout > 0 && !g.IsExhibition() {
+			view.TurnRemainingMS = max(turnTimeout-time.Since(g.TurnStartedAt), 0).Milliseconds()
+		}
+	}
+	return view
+}
@@ -0,0 +1,194 @@
+// Package engine runs external AI programs that speak a line-based
+// protocol on stdin and stdout, in the spirit of UCI for chess engines.
+//
+// The server starts the program and sends
+//
+//	ttt 1
+//
+// to which the engine answers "ready" once it can play. For every move the
+// server sends the board and the mark to play, with X, O and - for an empty
+// cell, positions 1 to 9 left to right and top to bottom:
+//
+//	position X-O-X---- O
+//	go
+//
+// and the engine answers
+//
+//	bestmove 9
+//
+// Other lines from the engine are ignored, so it may print diagnostics.
+// "quit" asks the engine to exit. Anything the engine writes to stderr is
+// logged at debug level.
+package engine
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"tic-tac-toe/internal/domain/game"
+	"time"
+)
+
+// Process is an engine backed by an external program. The program is
+// started on the first move and restarted after it fails; moves from
+// different games are sent to it one at a time.
+type Process struct {
+	name    string
+	command []string
+	timeout time.Duration
+
+	mu    sync.Mutex
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	lines chan string
+}
+
+// New returns an engine named name that runs command, the program followed
+// by its arguments. timeout bounds the start-up handshake and each move.
+func New(name string, command []string, timeout time.Duration) (*Process, error) {
+	if len(command) == 0 {
+		return nil, errors.New("engine command is empty")
+	}
+	if _, err := exec.LookPath(command[0]); err != nil {
+		return nil, err
+	}
+	return &Process{name: name, command: command, timeout: timeout}, nil
+}
+
+func (p *Process) Name() string {
+	return p.name
+}
+
+// Move asks the engine for player's next move in g.
+func (p *Process) Move(g *game.Game, player string) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cmd == nil {
+		if err := p.start(); err != nil {
+			return -1, err
+		}
+	}
+	position, err := p.bestMove(g, g.SymbolOf(player))
+	if err != nil {
+		slog.Warn("engine failed", "engine", p.name, "game_id", g.ID, "error", err)
+		p.stop()
+		return -1, err
+	}
+	return position, nil
+}
+
+// Close stops the engine program.
+func (p *Process) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cmd != nil {
+		fmt.Fprintln(p.stdin, "quit")
+		p.stop()
+	}
+	return nil
+}
+
+func (p *Process) start() error {
+	cmd := exec.Command(p.command[0], p.command[1:]...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	lines := make(chan string, 16)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+	go func() {
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			slog.Debug("engine output", "engine", p.name, "line", scanner.Text())
+		}
+	}()
+	p.cmd, p.stdin, p.lines = cmd, stdin, lines
+	slog.Info("engine started", "engine", p.name, "pid", cmd.Process.Pid)
+
+	if _, err := fmt.Fprintln(stdin, "ttt 1"); err != nil {
+		p.stop()
+		return err
+	}
+	if _, err := p.expect("ready"); err != nil {
+		p.stop()
+		return fmt.Errorf("engine handshake: %w", err)
+	}
+	return nil
+}
+
+// stop kills the program; the next move starts a fresh one.
+func (p *Process) stop() {
+	p.stdin.Close()
+	p.cmd.Process.Kill()
+	go p.cmd.Wait()
+	p.cmd, p.stdin, p.lines = nil, nil, nil
+}
+
+func (p *Process) bestMove(g *game.Game, symbol string) (int, error) {
+	var board strings.Builder
+	for _, cell := range g.Board {
+		if cell == " " {
+			board.WriteString("-")
+		} else {
+			board.WriteString(cell)
+		}
+	}
+	if _, err := fmt.Fprintf(p.stdin, "position %s %s\ngo\n", board.String(), symbol); err != nil {
+		return -1, err
+	}
+	reply, err := p.expect("bestmove")
+	if err != nil {
+		return -1, err
+	}
+	position, err := strconv.Atoi(reply)
+	if err != nil || position < 1 || position > 9 {
+		return -1, fmt.Errorf("invalid move %q", reply)
+	}
+	if g.Board[position-1] != " " {
+		return -1, fmt.Errorf("cell %d is already taken", position)
+	}
+	return position - 1, nil
+}
+
+// expect waits for a line starting with keyword and returns the rest of it.
+func (p *Process) expect(keyword string) (string, error) {
+	timer := time.NewTimer(p.timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case line, ok := <-p.lines:
+			if !ok {
+				return "", errors.New("engine exited")
+			}
+			fields := strings.Fields(line)
+			if len(fields) > 0 && fields[0] == keyword {
+				return strings.Join(fields[1:], " "), nil
+			}
+		case <-timer.C:
+			return "", fmt.Errorf("no %q within %s", keyword, p.timeout)
+		}
+	}
+}
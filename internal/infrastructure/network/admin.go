@@ -0,0 +1,96 @@
+package network
+
+import (
+	"errors"
+	"log/slog"
+	"net"
+	"tic-tac-toe/internal/domain/ban"
+	"tic-tac-toe/internal/domain/user"
+	"tic-tac-toe/internal/types"
+	"time"
+)
+
+func (s *TCPServer) IsAdmin(username string) bool {
+	u, err := s.userRepo.FindByUsername(username)
+	if err != nil {
+		return false
+	}
+	return u.IsAdmin()
+}
+
+// SetAdmin grants or revokes the admin role of an existing user.
+func (s *TCPServer) SetAdmin(username string, admin bool) error {
+	u, err := s.userRepo.FindByUsername(username)
+	if err != nil {
+		return err
+	}
+	u.Role = user.RolePlayer
+	if admin {
+		u.Role = user.RoleAdmin
+	}
+	slog.Info("role changed", "username", username, "role", u.Role)
+	return s.userRepo.Save(u)
+}
+
+// Kick disconnects an online player through the normal exit path.
+func (s *TCPServer) Kick(username string, reason string) error {
+	player := s.GetPlayer(username)
+	if player == nil {
+		return errors.New("player not online")
+	}
+	s.disconnect(player, reason)
+	return nil
+}
+
+// Ban adds a username or IP address to the ban list and disconnects any
+// matching players.
+func (s *TCPServer) Ban(target string, bannedBy string) error {
+	b := &ban.Ban{Target: target, BannedBy: bannedBy, BannedAt: time.Now()}
+	if err := s.banRepo.Save(b); err != nil {
+		return err
+	}
+	slog.Info("ban added", "username", bannedBy, "target", target)
+	var banned []*types.Player
+	s.mu.Lock()
+	for _, p := range s.players {
+		if p.Username == target || (b.IsIP() && remoteIP(p.Conn) == target) {
+			banned = append(banned, p)
+		}
+	}
+	s.mu.Unlock()
+	for _, p := range banned {
+		s.disconnect(p, "You have been banned.")
+	}
+	return nil
+}
+
+func (s *TCPServer) Unban(target string) error {
+	return s.banRepo.Delete(target)
+}
+
+// Broadcast sends a message to every connected player.
+func (s *TCPServer) Broadcast(message string) {
+	for _, p := range s.GetPlayers() {
+		types.SendMessage(p, message)
+	}
+}
+
+func (s *TCPServer) disconnect(player *types.Player, reason string) {
+	player.Logger().Info("disconnecting player", "reason", reason)
+	types.SendMessage(player, reason)
+	s.ExitPlayer(player)
+	if player.Conn != nil {
+		player.Conn.Close()
+	}
+}
+
+func remoteIP(conn net.Conn) string {
+	if conn == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(conn.RemoteAddr().String())
+	if err != nil {
+		return conn.RemoteAddr().String()
+	}
+	return host
+}
@@ -0,0 +1,51 @@
+package network
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"tic-tac-toe/internal/domain/game"
+	"tic-tac-toe/internal/domain/user"
+)
+
+// RegisterBot creates the bot account username, or gives an existing bot a
+// new token, and returns the token. Bots log in by sending
+// "bot <username> <token>" at the username prompt.
+func (s *TCPServer) RegisterBot(username string) (string, error) {
+	if username == "" {
+		return "", errors.New("username required")
+	}
+	if game.IsAIName(username) {
+		return "", errors.New("username is reserved for the AI")
+	}
+	u, err := s.userRepo.FindByUsername(username)
+	if err != nil {
+		u = user.NewUser(username)
+	} else if !u.IsBot {
+		return "", errors.New("username belongs to a player")
+	}
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(secret)
+	u.SetBotToken(token)
+	if err := s.userRepo.Save(u); err != nil {
+		return "", err
+	}
+	slog.Info("bot token issued", "username", username)
+	return token, nil
+}
+
+// botAccount reports whether username belongs to a bot, which can only log
+// in with its token.
+func (s *TCPServer) botAccount(username string) bool {
+	u, err := s.userRepo.FindByUsername(username)
+	return err == nil && u.IsBot
+}
+
+func (s *TCPServer) botTokenValid(username, token string) bool {
+	u, err := s.userRepo.FindByUsername(username)
+	return err == nil && u.CheckBotToken(token)
+}
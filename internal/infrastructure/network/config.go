@@ -0,0 +1,77 @@
+package network
+
+import "time"
+
+// Config holds the server's listen addresses and tunable settings.
+type Config struct {
+	// Addr is the plaintext listen address; empty disables it.
+	Addr string
+	// TLSAddr is the TLS listen address; empty disables it. Without
+	// TLSCertFile and TLSKeyFile a self-signed certificate is generated.
+	TLSAddr     string
+	TLSCertFile string
+	TLSKeyFile  string
+	// TelnetAddr is the listen address for telnet clients, which get option
+	// negotiation, server-side line editing and history; empty disables it.
+	TelnetAddr string
+	// SSHAddr is the SSH listen address; empty disables it. The host key is
+	// read from SSHHostKeyFile, which is generated if it does not exist.
+	SSHAddr        string
+	SSHHostKeyFile string
+	Admins         []string
+	// Engines maps an engine name, played with "join ai <name>", to the
+	// command line of an external engine program. EngineTimeout bounds its
+	// start-up and each of its moves.
+	Engines       map[string]string
+	EngineTimeout time.Duration
+	// MCTSPlayouts and MCTSBudget limit each move of the "mcts" engine, by
+	// random playouts and by time; zero means no limit for either.
+	MCTSPlayouts int
+	MCTSBudget   time.Duration
+	// ExhibitionMoveDelay is the pause before each move of a game between
+	// two AIs, so spectators can follow it.
+	ExhibitionMoveDelay time.Duration
+
+	// Idle timeouts for a client waiting at the username prompt, in the
+	// lobby (including while the opponent is thinking) and on its own turn.
+	// Zero disables the timeout.
+	UsernameTimeout time.Duration
+	LobbyTimeout    time.Duration
+	TurnTimeout     time.Duration
+	// IdleWarning is how long before an idle disconnect the client is warned.
+	IdleWarning time.Duration
+
+	// Connection caps. Zero means unlimited.
+	MaxConnections      int
+	MaxConnectionsPerIP int
+	// Token-bucket limits on the lines a client may send, per connection
+	// and shared by every connection from the same IP. A zero rate disables
+	// the limit.
+	CommandRate    float64
+	CommandBurst   int
+	IPCommandRate  float64
+	IPCommandBurst int
+}
+
+// DefaultConfig returns the settings used when no flags are given.
+func DefaultConfig() Config {
+	return Config{
+		Addr:                ":5000",
+		SSHHostKeyFile:      "ssh_host_ed25519_key",
+		EngineTimeout:       5 * time.Second,
+		MCTSPlayouts:        20000,
+		MCTSBudget:          time.Second,
+		ExhibitionMoveDelay: 2 * time.Second,
+		UsernameTimeout:     time.Minute,
+		LobbyTimeout:        10 * time.Minute,
+		TurnTimeout:         2 * time.Minute,
+		IdleWarning:         30 * time.Second,
+
+		MaxConnections:      1000,
+		MaxConnectionsPerIP: 20,
+		CommandRate:         5,
+		CommandBurst:        10,
+		IPCommandRate:       20,
+		IPCommandBurst:      40,
+	}
+}
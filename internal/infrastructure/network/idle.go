@@ -0,0 +1,77 @@
+package network
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"tic-tac-toe/internal/types"
+	"time"
+)
+
+// idlePollInterval is how often a blocked read wakes up to check whether
+// the client has been idle for too long.
+const idlePollInterval = time.Second
+
+var errIdle = errors.New("idle timeout")
+
+// readLine reads the next line from the client. While waiting it applies the
+// idle timeout for the player's current state, warning the client before
+// giving up with errIdle.
+func (s *TCPServer) readLine(player *types.Player, reader *bufio.Reader) (string, error) {
+	var line string
+	lastActivity := time.Now()
+	var warnedAt time.Time
+	for {
+		player.Conn.SetReadDeadline(time.Now().Add(idlePollInterval))
+		chunk, err := reader.ReadString('\n')
+		line += chunk
+		if err == nil {
+			player.Conn.SetReadDeadline(time.Time{})
+			return line, nil
+		}
+		var netErr net.Error
+		if !errors.As(err, &netErr) || !netErr.Timeout() {
+			return line, err
+		}
+		if chunk != "" {
+			lastActivity = time.Now()
+		}
+
+		timeout, idleSince := s.idleTimeout(player)
+		if timeout <= 0 {
+			continue
+		}
+		if idleSince.Before(lastActivity) {
+			idleSince = lastActivity
+		}
+		idle := time.Since(idleSince)
+		if idle >= timeout {
+			return line, errIdle
+		}
+		if warnedAt.Before(idleSince) && idle >= timeout-s.config.IdleWarning {
+			remaining := (timeout - idle).Round(time.Second)
+			types.SendMessage(player, fmt.Sprintf("You will be disconnected in %s for inactivity. Send any command (e.g. ping) to stay connected.", remaining))
+			warnedAt = time.Now()
+		}
+	}
+}
+
+// idleTimeout returns the timeout that applies to the player right now and
+// the moment its clock started: the start of the player's turn when it is
+// their move, otherwise the zero time (meaning the last activity counts).
+func (s *TCPServer) idleTimeout(player *types.Player) (time.Duration, time.Time) {
+	if player.Username == "" {
+		return s.config.UsernameTimeout, time.Time{}
+	}
+	s.mu.Lock()
+	gameID := player.GameID
+	s.mu.Unlock()
+	if gameID != "" {
+		turn, startedAt, err := s.gameService.Turn(gameID)
+		if err == nil && turn == player.Username {
+			return s.config.TurnTimeout, startedAt
+		}
+	}
+	return s.config.LobbyTimeout, time.Time{}
+}
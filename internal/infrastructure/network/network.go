@@ -2,101 +2,371 @@ package network
 
 import (
 	"bufio"
-	"log"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net"
+	"os"
+	"sort"
 	"strings"
 	"sync"
 	"tic-tac-toe/internal/application"
+	"tic-tac-toe/internal/audit"
+	"tic-tac-toe/internal/domain/ai"
+	"tic-tac-toe/internal/domain/ban"
 	"tic-tac-toe/internal/domain/game"
+	"tic-tac-toe/internal/domain/result"
+	"tic-tac-toe/internal/domain/scoring"
+	"tic-tac-toe/internal/domain/season"
 	"tic-tac-toe/internal/domain/user"
 	"tic-tac-toe/internal/handler"
+	"tic-tac-toe/internal/infrastructure/engine"
+	"tic-tac-toe/internal/metrics"
+	"tic-tac-toe/internal/render"
 	"tic-tac-toe/internal/types"
+	"tic-tac-toe/pkg/protocol"
+	"time"
+
+	"golang.org/x/crypto/ssh"
 )
 
 type TCPServer struct {
-	listener    net.Listener
-	userRepo    user.UserRepository
-	gameService *application.GameService
-	matchmaking *application.MatchmakingService
-	leaderboard *application.LeaderboardService
-	players     map[string]*types.Player
-	gamePlayers map[string][]*types.Player
-	mu          sync.Mutex // for thread safety
-}
-
-func NewTCPServer(addr string, userRepo user.UserRepository, gameRepo game.GameRepository) *TCPServer {
-	gameService := application.NewGameService(gameRepo, userRepo)
-	leaderboard := application.NewLeaderboardService(userRepo)
+	config         Config
+	listeners      []net.Listener
+	telnetListener net.Listener
+	sshListener    net.Listener
+	sshConfig      *ssh.ServerConfig
+	limiter        *connLimiter
+	auditLog       *audit.Log
+	userRepo       user.UserRepository
+	banRepo        ban.BanRepository
+	admins         map[string]bool
+	gameService    *application.GameService
+	matchmaking    *application.MatchmakingService
+	leaderboard    *application.LeaderboardService
+	players        map[string]*types.Player
+	gamePlayers    map[string][]*types.Player
+	spectators     map[string][]*types.Player
+	mu             sync.Mutex // for thread safety
+}
+
+func NewTCPServer(config Config, userRepo user.UserRepository, gameRepo game.GameRepository, resultRepo result.ResultRepository, seasonRepo season.SeasonRepository, banRepo ban.BanRepository, menaceRepo ai.MenaceRepository, rules *scoring.Rules, auditLog *audit.Log) *TCPServer {
+	achievements := application.NewAchievementService(userRepo)
+	gameService := application.NewGameService(gameRepo, userRepo, resultRepo, achievements, rules, auditLog)
+	leaderboard := application.NewLeaderboardService(userRepo, resultRepo, seasonRepo, achievements)
 	matchmaking := application.NewMatchmakingService(gameRepo)
-	listener, err := net.Listen("tcp", addr)
-	if err != nil {
-		log.Fatalf("Failed to create listener: %v", err)
+	metrics.NewGaugeFunc("ttt_active_games", "Games in progress, by mode.", "mode", gameService.ActiveGamesByMode)
+	metrics.NewGaugeFunc("ttt_matchmaking_queue_length", "Players waiting for a two-player opponent.", "", func() map[string]float64 {
+		return map[string]float64{"": float64(matchmaking.QueueLength())}
+	})
+	adminSet := make(map[string]bool)
+	for _, username := range config.Admins {
+		adminSet[username] = true
 	}
-	return &TCPServer{
-		listener:    listener,
+	s := &TCPServer{
+		config:      config,
+		limiter:     newConnLimiter(config),
+		auditLog:    auditLog,
 		userRepo:    userRepo,
+		banRepo:     banRepo,
+		admins:      adminSet,
 		gameService: gameService,
 		leaderboard: leaderboard,
 		matchmaking: matchmaking,
 		players:     make(map[string]*types.Player),
 		gamePlayers: make(map[string][]*types.Player),
+		spectators:  make(map[string][]*types.Player),
+	}
+	mcts, err := ai.NewMCTS(config.MCTSPlayouts, config.MCTSBudget)
+	if err == nil {
+		err = gameService.RegisterEngine(mcts)
+	}
+	if err != nil {
+		slog.Error("failed to register engine", "engine", "mcts", "error", err)
+		os.Exit(1)
+	}
+	menace, err := ai.NewMenace(menaceRepo)
+	if err == nil {
+		err = gameService.RegisterEngine(menace)
+	}
+	if err != nil {
+		slog.Error("failed to register engine", "engine", "menace", "error", err)
+		os.Exit(1)
 	}
+	for name, command := range config.Engines {
+		e, err := engine.New(name, strings.Fields(command), config.EngineTimeout)
+		if err == nil {
+			err = gameService.RegisterEngine(e)
+		}
+		if err != nil {
+			slog.Error("failed to register engine", "engine", name, "error", err)
+			os.Exit(1)
+		}
+	}
+	if err := s.listen(); err != nil {
+		slog.Error("failed to create listener", "error", err)
+		os.Exit(1)
+	}
+	return s
 }
 
+// listen opens the plaintext, TLS, telnet and SSH listeners enabled in the config.
+func (s *TCPServer) listen() error {
+	config := s.config
+	if config.Addr != "" {
+		listener, err := net.Listen("tcp", config.Addr)
+		if err != nil {
+			return err
+		}
+		s.listeners = append(s.listeners, listener)
+	}
+	if config.TLSAddr != "" {
+		tlsConfig, err := newTLSConfig(config)
+		if err != nil {
+			return err
+		}
+		listener, err := tls.Listen("tcp", config.TLSAddr, tlsConfig)
+		if err != nil {
+			return err
+		}
+		s.listeners = append(s.listeners, listener)
+	}
+	if config.TelnetAddr != "" {
+		listener, err := net.Listen("tcp", config.TelnetAddr)
+		if err != nil {
+			return err
+		}
+		s.telnetListener = listener
+	}
+	if config.SSHAddr != "" {
+		sshConfig, err := s.newSSHConfig()
+		if err != nil {
+			return err
+		}
+		listener, err := net.Listen("tcp", config.SSHAddr)
+		if err != nil {
+			return err
+		}
+		s.sshConfig = sshConfig
+		s.sshListener = listener
+	}
+	if len(s.listeners) == 0 && s.telnetListener == nil && s.sshListener == nil {
+		return errors.New("no listen address configured")
+	}
+	return nil
+}
+
+// Start accepts connections on every listener until one of them fails.
 func (s *TCPServer) Start() error {
+	errs := make(chan error, len(s.listeners)+2)
+	for _, listener := range s.listeners {
+		go func(listener net.Listener) {
+			errs <- s.serve(listener, s.handleClient)
+		}(listener)
+	}
+	if s.telnetListener != nil {
+		go func() {
+			errs <- s.serve(s.telnetListener, s.handleTelnet)
+		}()
+	}
+	if s.sshListener != nil {
+		go func() {
+			errs <- s.serve(s.sshListener, s.handleSSH)
+		}()
+	}
+	return <-errs
+}
+
+// serve accepts connections on listener, admits them through the
+// connection limiter and hands each one to handle in its own goroutine.
+func (s *TCPServer) serve(listener net.Listener, handle func(net.Conn)) error {
 	for {
-		conn, err := s.listener.Accept()
+		conn, err := listener.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return err
+		}
 		if err != nil {
-			log.Printf("Error accepting connection: %v", err)
+			slog.Warn("error accepting connection", "error", err)
+			continue
+		}
+		ip := remoteIP(conn)
+		if reason, ok := s.limiter.admit(ip); !ok {
+			slog.Info("refused connection", "remote_addr", conn.RemoteAddr().String(), "reason", reason)
+			metrics.ConnectionsRefused.Inc()
+			// On the TLS listener the write runs the handshake, which a
+			// client can stall, so it must not hold up Accept.
+			go func() {
+				conn.SetDeadline(time.Now().Add(tlsHandshakeTimeout))
+				conn.Write([]byte(reason + "\n"))
+				conn.Close()
+			}()
 			continue
 		}
-		go s.handleClient(conn)
+		go func() {
+			defer s.limiter.release(ip)
+			handle(conn)
+		}()
 	}
 }
 
 func (s *TCPServer) handleClient(conn net.Conn) {
 	defer conn.Close()
+	if err := handshake(conn); err != nil {
+		slog.Info("TLS handshake failed", "remote_addr", conn.RemoteAddr().String(), "error", err)
+		return
+	}
+	// Telnet clients on the plain ports may still send IAC sequences.
+	s.serveSession(types.NewPlayer(newTelnetConn(conn, false)), "", false)
+}
+
+// handleTelnet negotiates telnet options with the client and serves it with
+// server-side line editing, picking a display mode from its terminal type.
+func (s *TCPServer) handleTelnet(conn net.Conn) {
+	defer conn.Close()
+	telnet := newTelnetConn(conn, true)
+	telnet.negotiate()
+	player := types.NewPlayer(telnet)
+	player.Display = render.DefaultMode(telnet.TerminalType())
+	s.serveSession(player, "", false)
+}
+
+var (
+	errUsernameBanned    = errors.New("username is banned")
+	errUsernameTaken     = errors.New("username already taken")
+	errUsernameProtected = errors.New("username is protected")
+	errThrottled         = errors.New("you are sending commands too fast. Please slow down.")
+)
+
+// serveSession runs the login and command loops for player's connection.
+// A non-empty username was given to the front end the client connected
+// through, such as SSH, and skips the username prompt; authenticated
+// reports whether the front end also checked a credential for it.
+func (s *TCPServer) serveSession(player *types.Player, username string, authenticated bool) {
+	conn := player.Conn
+	metrics.ConnectedPlayers.Inc()
+	defer metrics.ConnectedPlayers.Dec()
 	reader := bufio.NewReader(conn)
+	connBucket := newTokenBucket(s.config.CommandRate, s.config.CommandBurst)
+	ipBucket := s.limiter.bucket(remoteIP(conn))
 
-	// Get Username from User
-	player := types.NewPlayer(conn)
-	types.SendMessage(player, "Welcome to Tic Tac Toe!\nEnter username: ")
+	if s.banRepo.IsBanned(remoteIP(conn)) {
+		player.Logger().Info("refused banned address")
+		types.SendMessage(player, "You are banned from this server.")
+		return
+	}
 
-	for {
-		username, err := reader.ReadString('\n')
-		if err != nil {
-			log.Printf("Error reading from %s: %v", player.Username, err)
-			s.mu.Lock()
-			delete(s.players, player.Username)
-			s.mu.Unlock()
+	if username != "" {
+		switch err := s.login(player, username, authenticated); {
+		case errors.Is(err, errUsernameBanned):
+			types.SendMessage(player, "This username is banned.")
+			return
+		case errors.Is(err, errUsernameProtected):
+			types.SendMessage(player, "This username is protected. Log in with its key or password.")
+			return
+		case err != nil:
+			types.SendMessage(player, "Username already taken. Disconnect your other session and try again.")
 			return
 		}
-		username = strings.TrimSpace(username)
-		if _, err := s.userRepo.FindByUsername(username); err == nil {
-			types.SendMessage(player, "Username already taken. Please choose another one:")
-		} else {
-			player.Username = username
-			u := user.NewUser(username)
-			s.userRepo.Save(u)
-			s.mu.Lock()
-			s.players[username] = player
-			s.mu.Unlock()
-			types.SendMessage(player, "Welcome, "+username)
-			types.SendMessage(player, "Commands: join <two-player|ai>, move <1-9>, leaderboard, exit")
-			break
+	} else {
+		// Get Username from User
+		types.SendMessage(player, "Welcome to Tic Tac Toe!\nEnter username: ")
+		// reject refuses a username: as plain text, or as an error event
+		// for clients that switched to the JSON protocol at the prompt.
+		reject := func(message string) {
+			if player.JSON {
+				types.SendEvent(player, protocol.Event{Type: protocol.EventError, Text: message})
+			} else {
+				types.SendMessage(player, message)
+			}
+		}
+		for {
+			username, err := s.readLine(player, reader)
+			if err != nil {
+				player.Logger().Info("connection closed before login", "error", err)
+				if errors.Is(err, errIdle) {
+					types.SendMessage(player, "Disconnected for inactivity.")
+				}
+				return
+			}
+			if s.throttled(player, connBucket, ipBucket) {
+				continue
+			}
+			username = strings.TrimSpace(username)
+			fields := strings.Fields(username)
+			if len(fields) == 2 && fields[0] == "protocol" {
+				if err := handler.HandleCommand(player, fields[0], fields[1:], s.gameService, s.leaderboard, s.matchmaking, s); err != nil {
+					types.SendError(player, err)
+				}
+				continue
+			}
+			authenticated = false
+			if len(fields) == 3 && fields[0] == "bot" {
+				if !s.botTokenValid(fields[1], fields[2]) {
+					player.Logger().Info("refused bot login", "requested_username", fields[1])
+					reject("Invalid bot name or token.")
+					continue
+				}
+				username, authenticated = fields[1], true
+			} else if len(fields) == 3 && fields[0] == "login" {
+				if !s.passwordValid(fields[1], fields[2]) {
+					player.Logger().Info("refused password login", "requested_username", fields[1])
+					reject("Invalid username or password.")
+					continue
+				}
+				username, authenticated = fields[1], true
+			} else if s.botAccount(username) {
+				reject("This username belongs to a bot. Log in with \"bot <name> <token>\" or choose another one:")
+				continue
+			}
+			if username == "" {
+				reject("Username cannot be empty. Please choose another one:")
+				continue
+			}
+			if !authenticated && s.passwordRequired(username) {
+				reject("This username is protected by a password. Log in with \"login <name> <password>\" or choose another one:")
+				continue
+			}
+			if !authenticated && s.sshKeyRequired(username) {
+				reject("This username is protected by an SSH key. Log in over SSH or choose another one:")
+				continue
+			}
+			if !authenticated && s.admins[username] {
+				reject("This username is reserved for an admin. Log in with \"login <name> <password>\" or choose another one:")
+				continue
+			}
+			err = s.login(player, username, authenticated)
+			if errors.Is(err, errUsernameBanned) {
+				reject("This username is banned.")
+				return
+			}
+			if err == nil {
+				break
+			}
+			if errors.Is(err, errUsernameProtected) {
+				reject("This username is protected. Log in with its key or password, or choose another one:")
+				continue
+			}
+			reject("Username already taken. Please choose another one:")
 		}
 	}
+	s.BroadcastLobby()
 
 	// Command loop
 	for {
-		message, err := reader.ReadString('\n')
+		message, err := s.readLine(player, reader)
 		if err != nil {
-			log.Printf("Error reading from %s: %v", player.Username, err)
-			s.mu.Lock()
-			delete(s.players, player.Username)
-			s.mu.Unlock()
+			player.Logger().Info("connection closed", "error", err)
+			if errors.Is(err, errIdle) {
+				types.SendMessage(player, "Disconnected for inactivity.")
+			}
+			s.ExitPlayer(player)
 			return
 		}
+		if s.throttled(player, connBucket, ipBucket) {
+			continue
+		}
 		message = strings.TrimSpace(message)
 		parts := strings.Split(message, " ")
 		if len(parts) == 0 {
@@ -104,27 +374,173 @@ func (s *TCPServer) handleClient(conn net.Conn) {
 		}
 		command := parts[0]
 		args := parts[1:]
-		if err := handler.HandleCommand(player, command, args, s.gameService, s.leaderboard, s.matchmaking, s); err != nil {
+		logged := args
+		if command == "password" {
+			// Keep passwords out of the logs.
+			logged = nil
+		}
+		player.Logger().Debug("command received", "command", command, "args", logged)
+		entry := audit.Entry{
+			Event:      audit.EventCommand,
+			Username:   player.Username,
+			GameID:     player.GameID,
+			RemoteAddr: conn.RemoteAddr().String(),
+			Command:    command,
+			Args:       logged,
+		}
+		err = handler.HandleCommand(player, command, args, s.gameService, s.leaderboard, s.matchmaking, s)
+		if err != nil {
+			entry.Error = err.Error()
+		}
+		s.auditLog.Record(entry)
+		if err != nil {
 			if err.Error() == "exit requested" {
 				return // clean exit
 			}
-			types.SendMessage(player, "Error: "+err.Error())
+			metrics.CommandErrors.Inc(handler.MetricsLabel(command), handler.ErrorCategory(err))
+			types.SendError(player, err)
 		}
 	}
 }
 
-func (s *TCPServer) AddPlayerToGame(gameID string, player *types.Player) {
+// login reserves username for player while it is online and loads the
+// user. Only a client that authenticated gets a stored account back, with
+// its stats; any other client logs in as a guest with a fresh account, and
+// may not take a name protected by a credential.
+func (s *TCPServer) login(player *types.Player, username string, authenticated bool) error {
+	if s.banRepo.IsBanned(username) {
+		player.Logger().Info("refused banned user", "requested_username", username)
+		return errUsernameBanned
+	}
+	if game.IsAIName(username) {
+		return errUsernameTaken
+	}
 	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.gamePlayers[gameID] = append(s.gamePlayers[gameID], player)
+	_, online := s.players[username]
+	if !online {
+		player.Username = username
+		s.players[username] = player
+	}
+	s.mu.Unlock()
+	if online {
+		return errUsernameTaken
+	}
+	u, err := s.userRepo.FindByUsername(username)
+	if !authenticated && (s.admins[username] || (err == nil && u.Protected())) {
+		s.mu.Lock()
+		delete(s.players, username)
+		player.Username = ""
+		s.mu.Unlock()
+		return errUsernameProtected
+	}
+	if err != nil || !authenticated {
+		u = user.NewUser(username)
+	}
+	// Admins named on the command line get the role only once they have
+	// proved who they are; guests cannot use their names at all.
+	if authenticated && s.admins[username] {
+		u.Role = user.RoleAdmin
+	}
+	player.Bot = u.IsBot
+	s.userRepo.Save(u)
+	player.Logger().Info("player logged in")
+	s.auditLog.Record(audit.Entry{Event: audit.EventLogin, Username: username, RemoteAddr: player.Conn.RemoteAddr().String()})
+	types.SendMessage(player, "Welcome, "+username)
+	types.SendEvent(player, protocol.Event{Type: protocol.EventLogin, Username: username})
+	types.SendMessage(player, "Commands: join <two-player|ai [easy|medium|hard|<engine>] [--as X|O|random]>, challenge <user>, accept <user>, decline <user>, exhibition <level> <level>, spectate [game-id|off], move <1-9>, hint, analyze, review, display [plain|color|redraw], leaderboard [daily|weekly|monthly|all] [ai|two-player] [top N], leaderboard season [n], leaderboard bots [top N], rank, profile [username], achievements, say <message>, lobby, ping, password <new>, exit")
+	return nil
+}
+
+// throttled consumes a token from the connection's and the IP's buckets and
+// tells the client when either is empty so the line is dropped.
+func (s *TCPServer) throttled(player *types.Player, connBucket, ipBucket *tokenBucket) bool {
+	scope := ""
+	if !connBucket.Allow() {
+		scope = "connection"
+	} else if !ipBucket.Allow() {
+		scope = "ip"
+	}
+	if scope == "" {
+		return false
+	}
+	metrics.CommandsThrottled.Inc(scope)
+	player.Logger().Debug("command throttled", "scope", scope)
+	types.SendError(player, errThrottled)
+	return true
+}
+
+func (s *TCPServer) AddPlayersToGame(gameID string, players ...*types.Player) error {
+	s.mu.Lock()
+	for _, player := range players {
+		if player.GameID != "" {
+			s.mu.Unlock()
+			return errors.New(player.Username + " is in a game")
+		}
+	}
+	for _, player := range players {
+		s.removeSpectator(player)
+		player.GameID = gameID
+		s.gamePlayers[gameID] = append(s.gamePlayers[gameID], player)
+	}
+	s.mu.Unlock()
+	s.BroadcastLobby()
+	return nil
 }
 
 func (s *TCPServer) BroadcastToGame(gameID string, message string) {
+	for _, player := range s.audience(gameID) {
+		types.SendMessage(player, message)
+	}
+}
+
+func (s *TCPServer) BroadcastFunc(gameID string, send func(player *types.Player)) {
+	for _, player := range s.audience(gameID) {
+		send(player)
+	}
+}
+
+// audience returns the players and spectators of the game. Messages are
+// sent to them after s.mu is released, so a client that stops reading
+// holds up only the goroutine writing to it.
+func (s *TCPServer) audience(gameID string) []*types.Player {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	for _, player := range s.gamePlayers[gameID] {
-		types.SendMessage(player, message)
+	players := append([]*types.Player(nil), s.gamePlayers[gameID]...)
+	return append(players, s.spectators[gameID]...)
+}
+
+func (s *TCPServer) Lobby() protocol.Lobby {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	lobby := protocol.Lobby{Players: make([]protocol.LobbyPlayer, 0, len(s.players))}
+	for username, player := range s.players {
+		entry := protocol.LobbyPlayer{Username: username, Status: protocol.StatusIdle, Bot: player.Bot}
+		if player.GameID != "" {
+			entry.Status = protocol.StatusPlaying
+			entry.GameID = player.GameID
+		} else if player.Watching != "" {
+			entry.Status = protocol.StatusWatching
+			entry.GameID = player.Watching
+		} else if s.matchmaking.IsWaiting(username) {
+			entry.Status = protocol.StatusWaiting
+		}
+		lobby.Players = append(lobby.Players, entry)
 	}
+	sort.Slice(lobby.Players, func(i, j int) bool {
+		return lobby.Players[i].Username < lobby.Players[j].Username
+	})
+	return lobby
+}
+
+func (s *TCPServer) BroadcastLobby() {
+	lobby := s.Lobby()
+	for _, player := range s.GetPlayers() {
+		types.SendEvent(player, protocol.Event{Type: protocol.EventLobby, Lobby: &lobby})
+	}
+}
+
+func (s *TCPServer) TurnTimeout() time.Duration {
+	return s.config.TurnTimeout
 }
 
 func (s *TCPServer) GetPlayer(username string) *types.Player {
@@ -133,22 +549,33 @@ func (s *TCPServer) GetPlayer(username string) *types.Player {
 	return s.players[username]
 }
 
+// GetPlayers returns a snapshot of the players online by username.
 func (s *TCPServer) GetPlayers() map[string]*types.Player {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	return s.players
+	players := make(map[string]*types.Player, len(s.players))
+	for username, player := range s.players {
+		players[username] = player
+	}
+	return players
 }
 
 func (s *TCPServer) ExitPlayer(player *types.Player) {
 	s.mu.Lock()
-	log.Printf("Exiting player: %s", player.Username)
-	delete(s.players, player.Username)
+	logger := player.Logger()
+	logger.Info("player exiting")
+	if s.players[player.Username] == player {
+		delete(s.players, player.Username)
+	}
+	s.matchmaking.RemoveFromWaiting(player.Username)
+	s.matchmaking.CancelChallenges(player.Username)
+	s.removeSpectator(player)
 
-	var remainingPlayers []*types.Player
+	var remainingPlayers, spectators []*types.Player
+	var leftGameID string
 	if player.GameID != "" {
 		// Player was in a game
 		gameID := player.GameID
-		log.Printf("%s was in game %s", player.Username, gameID)
 		gamePlayers := s.gamePlayers[gameID]
 		for i, p := range gamePlayers {
 			if p.Username == player.Username {
@@ -156,41 +583,102 @@ func (s *TCPServer) ExitPlayer(player *types.Player) {
 				break
 			}
 		}
-		remainingPlayers := s.gamePlayers[gameID]
-		log.Printf("Remaining players in game %s: %d", gameID, len(remainingPlayers))
+		remainingPlayers = s.gamePlayers[gameID]
+		logger.Debug("player left game", "remaining_players", len(remainingPlayers))
 
 		if len(remainingPlayers) == 1 {
 			remainingPlayer := remainingPlayers[0]
-			log.Printf("Notifying %s and moving to waiting queue", remainingPlayer.Username)
-			types.SendMessage(remainingPlayer, "Your opponent has left. Waiting for a new opponent...")
+			logger.Info("opponent moved to waiting queue", "opponent", remainingPlayer.Username)
 			s.matchmaking.AddToWaiting(remainingPlayer.Username)
 			remainingPlayer.GameID = ""
-			s.gameService.DeleteGame(gameID)
 		}
 
-		log.Printf("Deleting game %s from gamePlayers", gameID)
+		logger.Debug("game deleted")
+		spectators = s.dropSpectators(gameID)
 		delete(s.gamePlayers, gameID)
 		s.gameService.DeleteGame(gameID)
+		player.GameID = ""
+		leftGameID = gameID
 	}
 	s.mu.Unlock()
 
-	if remainingPlayers != nil && len(remainingPlayers) > 0 {
-		log.Printf("Broadcasting to game %s: %s has left", player.GameID, player.Username)
-		for _, p := range remainingPlayers {
-			types.SendMessage(p, player.Username+" has left the game.")
-		}
+	if len(remainingPlayers) == 1 {
+		logger.Debug("notifying remaining player")
+		types.SendMessage(remainingPlayers[0], "Your opponent has left. Waiting for a new opponent...")
+		types.SendEvent(remainingPlayers[0], protocol.Event{Type: protocol.EventGameEnd, Text: "Your opponent has left.", Game: &protocol.Game{ID: leftGameID}})
 	}
+	sendGameEnd(spectators, leftGameID, "The game has ended: "+player.Username+" left.")
+	s.BroadcastLobby()
 }
 
 func (s *TCPServer) EndGame(gameID string, message string) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	if players, ok := s.gamePlayers[gameID]; ok {
-		for _, p := range players {
-			types.SendMessage(p, message)
-			p.GameID = ""
+	entry := audit.Entry{Event: audit.EventGameEnd, GameID: gameID, Message: message, Outcome: "unfinished"}
+	if g, err := s.gameService.FindGameByID(gameID); err == nil {
+		if g.Winner != "" {
+			entry.Outcome = g.Winner + " won"
+		} else if g.IsDraw {
+			entry.Outcome = "draw"
 		}
-		delete(s.gamePlayers, gameID)
 	}
+	s.auditLog.Record(entry)
+	s.mu.Lock()
+	players := s.gamePlayers[gameID]
+	for _, p := range players {
+		p.GameID = ""
+	}
+	delete(s.gamePlayers, gameID)
+	spectators := s.dropSpectators(gameID)
 	s.gameService.DeleteGame(gameID)
+	s.mu.Unlock()
+	sendGameEnd(players, gameID, message)
+	sendGameEnd(spectators, gameID, message)
+	s.BroadcastLobby()
+}
+
+// sendGameEnd tells players that the game has ended with message.
+func sendGameEnd(players []*types.Player, gameID, message string) {
+	for _, p := range players {
+		types.SendMessage(p, message)
+		types.SendEvent(p, protocol.Event{Type: protocol.EventGameEnd, Text: message, Game: &protocol.Game{ID: gameID}})
+	}
+}
+
+// StartSeason archives the current season and announces the result to every
+// connected player.
+func (s *TCPServer) StartSeason() error {
+	archived, err := s.leaderboard.StartSeason()
+	if err != nil {
+		return err
+	}
+	message := fmt.Sprintf("Season %d has ended.", archived.Number)
+	if champion := archived.Champion(); champion != "" {
+		message += " Champion: " + champion + "!"
+	}
+	message += fmt.Sprintf(" Season %d starts now and the leaderboard has been reset.", archived.Number+1)
+	slog.Info("season started", "archived_season", archived.Number, "champion", archived.Champion())
+	for _, p := range s.GetPlayers() {
+		types.SendMessage(p, message)
+		for _, announcement := range s.gameService.TakeAnnouncements(p.Username) {
+			types.SendMessage(p, announcement)
+		}
+	}
+	return nil
+}
+
+// QueryAudit returns the most recent audit entries for a user or game as
+// JSON lines.
+func (s *TCPServer) QueryAudit(username, gameID string, limit int) ([]string, error) {
+	entries, err := s.auditLog.Query(username, gameID, limit)
+	if err != nil {
+		return nil, err
+	}
+	lines := make([]string, 0, len(entries))
+	for _, e := range entries {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, string(data))
+	}
+	return lines, nil
 }
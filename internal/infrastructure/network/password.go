@@ -0,0 +1,49 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"tic-tac-toe/internal/domain/game"
+	"tic-tac-toe/internal/domain/user"
+)
+
+// minPasswordLength is the shortest password accepted.
+const minPasswordLength = 8
+
+// SetPassword makes password log in as username, replacing any previous
+// password. Players log in with it by sending "login <username> <password>"
+// at the username prompt, or over SSH. Setting one on a guest's account
+// keeps the account for the player's next session.
+func (s *TCPServer) SetPassword(username, password string) error {
+	if len(password) < minPasswordLength {
+		return fmt.Errorf("password must be at least %d characters", minPasswordLength)
+	}
+	if game.IsAIName(username) {
+		return errors.New("username is reserved for the AI")
+	}
+	u, err := s.userRepo.FindByUsername(username)
+	if err != nil {
+		u = user.NewUser(username)
+	}
+	if u.IsBot {
+		return errors.New("bots log in with their token")
+	}
+	if err := u.SetPassword(password); err != nil {
+		return err
+	}
+	slog.Info("password set", "username", username)
+	return s.userRepo.Save(u)
+}
+
+// passwordRequired reports whether username has a password, which a client
+// must give unless it authenticated another way.
+func (s *TCPServer) passwordRequired(username string) bool {
+	u, err := s.userRepo.FindByUsername(username)
+	return err == nil && u.PasswordHash != ""
+}
+
+func (s *TCPServer) passwordValid(username, password string) bool {
+	u, err := s.userRepo.FindByUsername(username)
+	return err == nil && u.CheckPassword(password)
+}
@@ -0,0 +1,102 @@
+package network
+
+import (
+	"sync"
+	"time"
+)
+
+// tokenBucket allows bursts of up to burst events and refills at rate
+// events per second.
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	mu     sync.Mutex
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Allow takes a token if one is available. A bucket with a non-positive rate
+// never limits.
+func (b *tokenBucket) Allow() bool {
+	if b == nil || b.rate <= 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// connLimiter tracks open connections globally and per remote IP, and the
+// command rate shared by all connections from the same IP.
+type connLimiter struct {
+	config  Config
+	total   int
+	perIP   map[string]int
+	buckets map[string]*tokenBucket
+	mu      sync.Mutex
+}
+
+func newConnLimiter(config Config) *connLimiter {
+	return &connLimiter{
+		config:  config,
+		perIP:   make(map[string]int),
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// admit registers a new connection from ip, or returns the reason it must
+// be refused.
+func (l *connLimiter) admit(ip string) (string, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.config.MaxConnections > 0 && l.total >= l.config.MaxConnections {
+		return "Server is full. Please try again later.", false
+	}
+	if l.config.MaxConnectionsPerIP > 0 && l.perIP[ip] >= l.config.MaxConnectionsPerIP {
+		return "Too many connections from your address. Please close one and try again.", false
+	}
+	l.total++
+	l.perIP[ip]++
+	if _, ok := l.buckets[ip]; !ok {
+		l.buckets[ip] = newTokenBucket(l.config.IPCommandRate, l.config.IPCommandBurst)
+	}
+	return "", true
+}
+
+// release forgets a closed connection, dropping the IP's bucket once its
+// last connection is gone.
+func (l *connLimiter) release(ip string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.total--
+	l.perIP[ip]--
+	if l.perIP[ip] <= 0 {
+		delete(l.perIP, ip)
+		delete(l.buckets, ip)
+	}
+}
+
+func (l *connLimiter) bucket(ip string) *tokenBucket {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.buckets[ip]
+}
@@ -0,0 +1,66 @@
+package network
+
+import (
+	"tic-tac-toe/internal/types"
+	"time"
+)
+
+func (s *TCPServer) ExhibitionMoveDelay() time.Duration {
+	return s.config.ExhibitionMoveDelay
+}
+
+func (s *TCPServer) Spectate(gameID string, player *types.Player) {
+	s.mu.Lock()
+	s.removeSpectator(player)
+	s.spectators[gameID] = append(s.spectators[gameID], player)
+	player.Watching = gameID
+	s.mu.Unlock()
+	player.Logger().Info("spectating game", "watching", gameID)
+	s.BroadcastLobby()
+}
+
+func (s *TCPServer) StopSpectating(player *types.Player) {
+	s.mu.Lock()
+	s.removeSpectator(player)
+	s.mu.Unlock()
+	s.BroadcastLobby()
+}
+
+func (s *TCPServer) SpectatorCount(gameID string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.spectators[gameID])
+}
+
+// removeSpectator stops player watching their game, if any. The caller
+// must hold s.mu.
+func (s *TCPServer) removeSpectator(player *types.Player) {
+	gameID := player.Watching
+	if gameID == "" {
+		return
+	}
+	watchers := s.spectators[gameID]
+	for i, p := range watchers {
+		if p == player {
+			watchers = append(watchers[:i], watchers[i+1:]...)
+			break
+		}
+	}
+	if len(watchers) == 0 {
+		delete(s.spectators, gameID)
+	} else {
+		s.spectators[gameID] = watchers
+	}
+	player.Watching = ""
+}
+
+// dropSpectators stops everyone watching the game and returns them, to be
+// told that it ended once s.mu is released. The caller must hold s.mu.
+func (s *TCPServer) dropSpectators(gameID string) []*types.Player {
+	spectators := s.spectators[gameID]
+	for _, p := range spectators {
+		p.Watching = ""
+	}
+	delete(s.spectators, gameID)
+	return spectators
+}
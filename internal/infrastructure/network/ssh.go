@@ -0,0 +1,298 @@
+package network
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"sync/atomic"
+	"tic-tac-toe/internal/domain/game"
+	"tic-tac-toe/internal/domain/user"
+	"tic-tac-toe/internal/render"
+	"tic-tac-toe/internal/types"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/term"
+)
+
+// sshKeyExtension carries the fingerprint of the public key a client
+// authenticated with from the auth callback to the session.
+const sshKeyExtension = "pubkey-fp"
+
+// sshPasswordExtension marks a client that authenticated with the
+// account's password.
+const sshPasswordExtension = "password"
+
+// errBotAccount refuses SSH logins as a bot, which logs in with its token
+// over TCP instead.
+var errBotAccount = errors.New("username belongs to a bot")
+
+// errAdminReserved refuses guests, and first key claims, on the names
+// given with -admins. Their passwords are set by the server operator.
+var errAdminReserved = errors.New("username is reserved for an admin")
+
+// newSSHConfig authenticates SSH clients against the game's users. The SSH
+// username is the game username: a username with registered keys or a
+// password only accepts those, and any other username except a bot's is
+// open to the first client, whose public key (if it offers one) then
+// claims it. Clients without a key play as guests.
+func (s *TCPServer) newSSHConfig() (*ssh.ServerConfig, error) {
+	hostKey, err := loadOrCreateHostKey(s.config.SSHHostKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	sshConfig := &ssh.ServerConfig{
+		PublicKeyCallback: func(meta ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if s.botAccount(meta.User()) {
+				return nil, errBotAccount
+			}
+			fingerprint := ssh.FingerprintSHA256(key)
+			protected := s.sshKeyRequired(meta.User()) || s.passwordRequired(meta.User())
+			if protected && !s.sshKeyRegistered(meta.User(), fingerprint) {
+				return nil, errors.New("public key is not registered for this username")
+			}
+			return &ssh.Permissions{Extensions: map[string]string{sshKeyExtension: fingerprint}}, nil
+		},
+		KeyboardInteractiveCallback: func(meta ssh.ConnMetadata, challenge ssh.KeyboardInteractiveChallenge) (*ssh.Permissions, error) {
+			if s.botAccount(meta.User()) {
+				return nil, errBotAccount
+			}
+			if s.passwordRequired(meta.User()) {
+				answers, err := challenge("", "", []string{"Password: "}, []bool{false})
+				if err != nil {
+					return nil, err
+				}
+				if len(answers) != 1 || !s.passwordValid(meta.User(), answers[0]) {
+					return nil, errors.New("invalid password")
+				}
+				return &ssh.Permissions{Extensions: map[string]string{sshPasswordExtension: "ok"}}, nil
+			}
+			if s.sshKeyRequired(meta.User()) {
+				return nil, errors.New("username is protected by a public key")
+			}
+			if s.admins[meta.User()] {
+				return nil, errAdminReserved
+			}
+			return &ssh.Permissions{}, nil
+		},
+	}
+	sshConfig.AddHostKey(hostKey)
+	return sshConfig, nil
+}
+
+// loadOrCreateHostKey reads the SSH host key at path, generating and saving
+// a new ed25519 key the first time so clients see the same key across
+// restarts.
+func loadOrCreateHostKey(path string) (ssh.Signer, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		return ssh.ParsePrivateKey(data)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	block, err := ssh.MarshalPrivateKey(key, "tic-tac-toe host key")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0o600); err != nil {
+		return nil, err
+	}
+	slog.Info("generated SSH host key", "path", path)
+	return ssh.NewSignerFromKey(key)
+}
+
+// sshKeyRequired reports whether username can only log in over SSH with one
+// of its registered public keys.
+func (s *TCPServer) sshKeyRequired(username string) bool {
+	u, err := s.userRepo.FindByUsername(username)
+	return err == nil && len(u.SSHKeys) > 0
+}
+
+func (s *TCPServer) sshKeyRegistered(username, fingerprint string) bool {
+	u, err := s.userRepo.FindByUsername(username)
+	return err == nil && u.HasSSHKey(fingerprint)
+}
+
+// claimSSHKey registers fingerprint on username if the username is not
+// protected yet, in a new account replacing any guest's. It fails if the
+// username was protected in the meantime, or is in use by a guest.
+func (s *TCPServer) claimSSHKey(username, fingerprint string) error {
+	u, err := s.userRepo.FindByUsername(username)
+	if err == nil && u.HasSSHKey(fingerprint) {
+		return nil
+	}
+	if err == nil && u.Protected() {
+		return errors.New("public key is not registered for this username")
+	}
+	if s.admins[username] {
+		return errAdminReserved
+	}
+	if game.IsAIName(username) || s.GetPlayer(username) != nil {
+		return errUsernameTaken
+	}
+	u = user.NewUser(username)
+	u.AddSSHKey(fingerprint)
+	return s.userRepo.Save(u)
+}
+
+// handleSSH runs the SSH handshake and serves the client's first session
+// channel through the same login and command loops as the TCP front end.
+func (s *TCPServer) handleSSH(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(tlsHandshakeTimeout))
+	sshConn, channels, requests, err := ssh.NewServerConn(conn, s.sshConfig)
+	if err != nil {
+		slog.Info("SSH handshake failed", "remote_addr", conn.RemoteAddr().String(), "error", err)
+		return
+	}
+	defer sshConn.Close()
+	conn.SetDeadline(time.Time{})
+	go ssh.DiscardRequests(requests)
+
+	username := sshConn.User()
+	if fingerprint := sshConn.Permissions.Extensions[sshKeyExtension]; fingerprint != "" {
+		if err := s.claimSSHKey(username, fingerprint); err != nil {
+			slog.Info("refused SSH login", "username", username, "remote_addr", conn.RemoteAddr().String(), "error", err)
+			return
+		}
+	}
+
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "only session channels are supported")
+			continue
+		}
+		channel, channelRequests, err := newChannel.Accept()
+		if err != nil {
+			slog.Info("failed to accept SSH session", "username", username, "error", err)
+			return
+		}
+		s.serveSSHSession(sshConn, channel, channelRequests)
+		return
+	}
+}
+
+// serveSSHSession bridges an SSH session channel to serveSession. A
+// terminal on the channel provides echo and line editing; complete lines
+// are written into one end of a pipe, which serveSession reads like any
+// other connection.
+func (s *TCPServer) serveSSHSession(sshConn *ssh.ServerConn, channel ssh.Channel, requests <-chan *ssh.Request) {
+	defer channel.Close()
+	terminal := term.NewTerminal(channel, "")
+	local, remote := net.Pipe()
+	conn := &sshPipeConn{Conn: remote, remoteAddr: sshConn.RemoteAddr()}
+	// shell receives the client's TERM, empty without a pty, once the
+	// client asks for a shell. It is closed when the client stops sending
+	// requests.
+	shell := make(chan string, 1)
+	go func() {
+		defer close(shell)
+		terminalType := ""
+		for req := range requests {
+			switch req.Type {
+			case "pty-req":
+				var pty struct {
+					Term          string
+					Columns, Rows uint32
+					Width, Height uint32
+					Modes         string
+				}
+				if ssh.Unmarshal(req.Payload, &pty) == nil {
+					terminalType = pty.Term
+					if pty.Columns > 0 {
+						terminal.SetSize(int(pty.Columns), int(pty.Rows))
+						conn.width.Store(int32(pty.Columns))
+					}
+				}
+				req.Reply(true, nil)
+			case "window-change":
+				var size struct {
+					Columns, Rows uint32
+					Width, Height uint32
+				}
+				if ssh.Unmarshal(req.Payload, &size) == nil && size.Columns > 0 {
+					terminal.SetSize(int(size.Columns), int(size.Rows))
+					conn.width.Store(int32(size.Columns))
+				}
+			case "shell":
+				req.Reply(true, nil)
+				select {
+				case shell <- terminalType:
+				default:
+				}
+			case "env":
+				req.Reply(true, nil)
+			default:
+				req.Reply(false, nil)
+			}
+		}
+	}()
+
+	var terminalType string
+	select {
+	case t, ok := <-shell:
+		if !ok {
+			remote.Close()
+			return
+		}
+		terminalType = t
+	case <-time.After(tlsHandshakeTimeout):
+		slog.Info("SSH client did not request a shell", "username", sshConn.User(), "remote_addr", sshConn.RemoteAddr().String())
+		remote.Close()
+		return
+	}
+
+	go func() {
+		defer local.Close()
+		for {
+			line, err := terminal.ReadLine()
+			if err != nil {
+				return
+			}
+			if _, err := local.Write([]byte(line + "\n")); err != nil {
+				return
+			}
+		}
+	}()
+	done := make(chan struct{})
+	go func() {
+		io.Copy(terminal, local)
+		close(done)
+	}()
+
+	player := types.NewPlayer(conn)
+	player.Display = render.DefaultMode(terminalType)
+	extensions := sshConn.Permissions.Extensions
+	authenticated := extensions[sshKeyExtension] != "" || extensions[sshPasswordExtension] != ""
+	s.serveSession(player, sshConn.User(), authenticated)
+	remote.Close()
+	<-done
+	channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{0}))
+}
+
+// sshPipeConn is the game's end of an SSH session pipe. It reports the SSH
+// client's address so bans, rate limits and logs see the real client, and
+// the width of its pty.
+type sshPipeConn struct {
+	net.Conn
+	remoteAddr net.Addr
+	width      atomic.Int32
+}
+
+func (c *sshPipeConn) RemoteAddr() net.Addr {
+	return c.remoteAddr
+}
+
+func (c *sshPipeConn) TerminalWidth() int {
+	return int(c.width.Load())
+}
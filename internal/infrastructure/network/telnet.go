@@ -0,0 +1,382 @@
+package network
+
+import (
+	"net"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Telnet commands and options (RFC 854, 857, 858, 1073 and 1091).
+const (
+	telnetSE   = 240
+	telnetSB   = 250
+	telnetWILL = 251
+	telnetWONT = 252
+	telnetDO   = 253
+	telnetDONT = 254
+	telnetIAC  = 255
+
+	telnetOptEcho  = 1
+	telnetOptSGA   = 3
+	telnetOptTType = 24
+	telnetOptNAWS  = 31
+
+	telnetTTypeIS   = 0
+	telnetTTypeSend = 1
+)
+
+// telnetNegotiationTimeout bounds how long a telnet client is given to
+// report its terminal type before the session starts.
+const telnetNegotiationTimeout = time.Second
+
+// telnetHistorySize is how many lines the up and down arrows can recall.
+const telnetHistorySize = 50
+
+// Input parser states.
+const (
+	stateData = iota
+	stateIAC
+	stateOption
+	stateSB
+	stateSBIAC
+	stateEscape
+	stateCSI
+)
+
+// telnetConn speaks the telnet protocol over a client connection. Reads
+// return plain text lines with every IAC sequence removed. An interactive
+// connection also negotiates options with the client, echoes its input and
+// offers line editing and history; a passive one only strips and refuses
+// negotiation, so clients like nc see exactly the bytes the server writes.
+type telnetConn struct {
+	net.Conn
+	interactive bool
+
+	// mu guards the fields below. Writes to Conn are made without it, so a
+	// client that stops reading holds up only the goroutine writing to it.
+	mu           sync.Mutex
+	state        int
+	command      byte
+	subneg       []byte
+	lastCR       bool
+	line         []byte
+	ready        []byte
+	history      [][]byte
+	historyPos   int
+	width        int
+	terminalType string
+	ttypeDone    bool
+}
+
+func newTelnetConn(conn net.Conn, interactive bool) *telnetConn {
+	return &telnetConn{Conn: conn, interactive: interactive}
+}
+
+// negotiate offers server-side echo and character-at-a-time input and asks
+// for the client's window size and terminal type, waiting briefly for the
+// terminal type so the session can pick a display mode. Input typed in the
+// meantime is kept for the first Read.
+func (c *telnetConn) negotiate() {
+	c.Conn.Write([]byte{
+		telnetIAC, telnetWILL, telnetOptEcho,
+		telnetIAC, telnetWILL, telnetOptSGA,
+		telnetIAC, telnetDO, telnetOptSGA,
+		telnetIAC, telnetDO, telnetOptNAWS,
+		telnetIAC, telnetDO, telnetOptTType,
+	})
+
+	c.Conn.SetReadDeadline(time.Now().Add(telnetNegotiationTimeout))
+	defer c.Conn.SetReadDeadline(time.Time{})
+	buf := make([]byte, 512)
+	for {
+		c.mu.Lock()
+		done := c.ttypeDone
+		c.mu.Unlock()
+		if done {
+			return
+		}
+		n, err := c.Conn.Read(buf)
+		c.process(buf[:n])
+		if err != nil {
+			return
+		}
+	}
+}
+
+// TerminalType returns the terminal type the client reported, if any.
+func (c *telnetConn) TerminalType() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.terminalType
+}
+
+// TerminalWidth returns the window width the client reported, or 0.
+func (c *telnetConn) TerminalWidth() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.width
+}
+
+func (c *telnetConn) Read(p []byte) (int, error) {
+	buf := make([]byte, 512)
+	for {
+		c.mu.Lock()
+		if len(c.ready) > 0 {
+			n := copy(p, c.ready)
+			c.ready = c.ready[n:]
+			c.mu.Unlock()
+			return n, nil
+		}
+		c.mu.Unlock()
+		n, err := c.Conn.Read(buf)
+		c.process(buf[:n])
+		if err != nil {
+			c.mu.Lock()
+			pending := len(c.ready) > 0
+			c.mu.Unlock()
+			if !pending {
+				return 0, err
+			}
+		}
+	}
+}
+
+// Write sends p with newlines translated to CRLF. On an interactive
+// connection a line the client is still typing is moved below the output.
+func (c *telnetConn) Write(p []byte) (int, error) {
+	if !c.interactive {
+		return c.Conn.Write(p)
+	}
+	c.mu.Lock()
+	out := make([]byte, 0, len(p)+len(c.line)+8)
+	if len(c.line) > 0 {
+		out = append(out, '\r', '\n')
+	}
+	for _, b := range p {
+		switch b {
+		case '\n':
+			out = append(out, '\r', '\n')
+		case telnetIAC:
+			out = append(out, telnetIAC, telnetIAC)
+		default:
+			out = append(out, b)
+		}
+	}
+	out = append(out, c.line...)
+	c.mu.Unlock()
+	if _, err := c.Conn.Write(out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// process feeds raw bytes from the client through the parser, then sends
+// the client its echo and any replies to its negotiation.
+func (c *telnetConn) process(data []byte) {
+	if out := c.parse(data); len(out) > 0 {
+		c.Conn.Write(out)
+	}
+}
+
+// parse runs data through the parser and returns what the client should be
+// sent in reply: echo and option negotiation, in order.
+func (c *telnetConn) parse(data []byte) []byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var out []byte
+	for _, b := range data {
+		switch c.state {
+		case stateIAC:
+			switch b {
+			case telnetWILL, telnetWONT, telnetDO, telnetDONT:
+				c.command = b
+				c.state = stateOption
+			case telnetSB:
+				c.subneg = c.subneg[:0]
+				c.state = stateSB
+			case telnetIAC:
+				c.state = stateData
+				out = c.input(b, out)
+			default:
+				c.state = stateData
+			}
+		case stateOption:
+			out = c.option(c.command, b, out)
+			c.state = stateData
+		case stateSB:
+			if b == telnetIAC {
+				c.state = stateSBIAC
+			} else {
+				c.subneg = append(c.subneg, b)
+			}
+		case stateSBIAC:
+			switch b {
+			case telnetSE:
+				c.subnegotiation(c.subneg)
+				c.state = stateData
+			case telnetIAC:
+				c.subneg = append(c.subneg, b)
+				c.state = stateSB
+			default:
+				c.state = stateSB
+			}
+		case stateEscape:
+			if b == '[' || b == 'O' {
+				c.state = stateCSI
+			} else {
+				c.state = stateData
+			}
+		case stateCSI:
+			if b >= 0x40 && b <= 0x7e {
+				c.state = stateData
+				out = c.recall(b, out)
+			}
+		default:
+			if b == telnetIAC {
+				c.state = stateIAC
+				continue
+			}
+			out = c.input(b, out)
+		}
+	}
+	return out
+}
+
+// input handles one byte of client data and returns echo extended with
+// whatever the client should see.
+func (c *telnetConn) input(b byte, echo []byte) []byte {
+	cr := c.lastCR
+	c.lastCR = b == '\r'
+	if (b == '\n' || b == 0) && cr {
+		return echo // second half of a CR LF or CR NUL line ending
+	}
+	if !c.interactive {
+		switch b {
+		case 0:
+		case '\r':
+			c.ready = append(c.ready, '\n')
+		default:
+			c.ready = append(c.ready, b)
+		}
+		return echo
+	}
+	switch {
+	case b == '\r' || b == '\n':
+		if len(c.line) > 0 {
+			c.history = append(c.history, append([]byte(nil), c.line...))
+			if len(c.history) > telnetHistorySize {
+				c.history = c.history[1:]
+			}
+		}
+		c.historyPos = len(c.history)
+		c.ready = append(c.ready, c.line...)
+		c.ready = append(c.ready, '\n')
+		c.line = c.line[:0]
+		echo = append(echo, '\r', '\n')
+	case b == 0x7f || b == 0x08: // backspace
+		if len(c.line) > 0 {
+			_, size := utf8.DecodeLastRune(c.line)
+			c.line = c.line[:len(c.line)-size]
+			echo = append(echo, '\b', ' ', '\b')
+		}
+	case b == 0x15: // ctrl-u erases the line
+		echo = c.replaceLine(nil, echo)
+	case b == 0x17: // ctrl-w erases the last word
+		end := len(c.line)
+		for end > 0 && c.line[end-1] == ' ' {
+			end--
+		}
+		for end > 0 && c.line[end-1] != ' ' {
+			end--
+		}
+		echo = c.replaceLine(append([]byte(nil), c.line[:end]...), echo)
+	case b == 0x03: // ctrl-c cancels the line
+		c.line = c.line[:0]
+		echo = append(echo, '^', 'C', '\r', '\n')
+	case b == 0x1b:
+		c.state = stateEscape
+	case b >= 0x20:
+		c.line = append(c.line, b)
+		echo = append(echo, b)
+	}
+	return echo
+}
+
+// recall handles the final byte of a cursor key sequence: up and down walk
+// through the history.
+func (c *telnetConn) recall(key byte, echo []byte) []byte {
+	switch key {
+	case 'A':
+		if c.historyPos > 0 {
+			c.historyPos--
+			echo = c.replaceLine(c.history[c.historyPos], echo)
+		}
+	case 'B':
+		if c.historyPos < len(c.history) {
+			c.historyPos++
+			var line []byte
+			if c.historyPos < len(c.history) {
+				line = c.history[c.historyPos]
+			}
+			echo = c.replaceLine(line, echo)
+		}
+	}
+	return echo
+}
+
+// replaceLine erases the line being edited on the client's screen and
+// shows line in its place.
+func (c *telnetConn) replaceLine(line []byte, echo []byte) []byte {
+	for n := utf8.RuneCount(c.line); n > 0; n-- {
+		echo = append(echo, '\b', ' ', '\b')
+	}
+	c.line = append(c.line[:0], line...)
+	return append(echo, c.line...)
+}
+
+// option answers WILL, WONT, DO and DONT from the client, appending the
+// reply to out. An interactive connection accepts the options it offered
+// or requested; everything else is refused.
+func (c *telnetConn) option(command, option byte, out []byte) []byte {
+	switch command {
+	case telnetDO:
+		if c.interactive && (option == telnetOptEcho || option == telnetOptSGA) {
+			return out
+		}
+		return append(out, telnetIAC, telnetWONT, option)
+	case telnetWILL:
+		if c.interactive {
+			switch option {
+			case telnetOptSGA, telnetOptNAWS:
+				return out
+			case telnetOptTType:
+				return append(out, telnetIAC, telnetSB, telnetOptTType, telnetTTypeSend, telnetIAC, telnetSE)
+			}
+		}
+		return append(out, telnetIAC, telnetDONT, option)
+	case telnetWONT:
+		if option == telnetOptTType {
+			c.ttypeDone = true
+		}
+	}
+	return out
+}
+
+// subnegotiation reads the window size and terminal type reports.
+func (c *telnetConn) subnegotiation(data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	switch data[0] {
+	case telnetOptNAWS:
+		if len(data) >= 5 {
+			c.width = int(data[1])<<8 | int(data[2])
+		}
+	case telnetOptTType:
+		if len(data) >= 2 && data[1] == telnetTTypeIS {
+			c.terminalType = string(data[2:])
+			c.ttypeDone = true
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package network
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTelnetParseAcrossReads(t *testing.T) {
+	const (
+		IAC  = telnetIAC
+		WILL = telnetWILL
+		DO   = telnetDO
+		DONT = telnetDONT
+		WONT = telnetWONT
+		SB   = telnetSB
+		SE   = telnetSE
+	)
+	tests := []struct {
+		name        string
+		interactive bool
+		// reads are fed to the parser one at a time, as separate reads.
+		reads     [][]byte
+		wantInput string
+		wantReply []byte
+		wantWidth int
+		wantTerm  string
+	}{
+		{
+			name:      "WILL split after IAC",
+			reads:     [][]byte{[]byte("ab"), {IAC}, {WILL, telnetOptNAWS}, []byte("c\r\n")},
+			wantInput: "abc\n",
+			wantReply: []byte{IAC, DONT, telnetOptNAWS},
+		},
+		{
+			name:      "DO split before its option",
+			reads:     [][]byte{{IAC, DO}, {telnetOptEcho}, []byte("move 5\r\n")},
+			wantInput: "move 5\n",
+			wantReply: []byte{IAC, WONT, telnetOptEcho},
+		},
+		{
+			name:      "SB split inside its data and before SE",
+			reads:     [][]byte{{IAC, SB, telnetOptNAWS, 0}, {80, 0}, {24, IAC}, {SE}, []byte("hi\r\n")},
+			wantInput: "hi\n",
+			wantWidth: 80,
+		},
+		{
+			name:      "escaped IAC in SB data",
+			reads:     [][]byte{{IAC, SB, telnetOptNAWS, 0, IAC}, {IAC, 0, 24, IAC, SE}, []byte("x\n")},
+			wantInput: "x\n",
+			wantWidth: 255,
+		},
+		{
+			name:      "CR NUL split",
+			reads:     [][]byte{[]byte("ping\r"), {0}},
+			wantInput: "ping\n",
+		},
+		{
+			name:        "interactive terminal type split",
+			interactive: true,
+			reads: [][]byte{
+				{IAC, WILL}, {telnetOptTType},
+				{IAC, SB, telnetOptTType, telnetTTypeIS, 'x', 't'}, []byte("erm"), {IAC}, {SE},
+				[]byte("ok\r"), {'\n'},
+			},
+			wantInput: "ok\n",
+			wantReply: append([]byte{IAC, SB, telnetOptTType, telnetTTypeSend, IAC, SE}, "ok\r\n"...),
+			wantTerm:  "xterm",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTelnetConn(nil, tt.interactive)
+			var reply []byte
+			for _, read := range tt.reads {
+				reply = append(reply, c.parse(read)...)
+			}
+			if string(c.ready) != tt.wantInput {
+				t.Errorf("input %q, want %q", c.ready, tt.wantInput)
+			}
+			if !bytes.Equal(reply, tt.wantReply) {
+				t.Errorf("reply %v, want %v", reply, tt.wantReply)
+			}
+			if c.width != tt.wantWidth {
+				t.Errorf("width %d, want %d", c.width, tt.wantWidth)
+			}
+			if c.terminalType != tt.wantTerm {
+				t.Errorf("terminal type %q, want %q", c.terminalType, tt.wantTerm)
+			}
+			if c.state != stateData {
+				t.Errorf("parser left in state %d", c.state)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+
+	"tic-tac-toe/internal/domain/ban"
+)
+
+// FileBanRepository keeps the ban list in memory and rewrites it to a JSON
+// file on every change so bans survive restarts.
+type FileBanRepository struct {
+	path string
+	bans map[string]*ban.Ban
+	mu   sync.Mutex
+}
+
+func NewFileBanRepository(path string) (*FileBanRepository, error) {
+	r := &FileBanRepository{path: path, bans: make(map[string]*ban.Ban)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var bans []*ban.Ban
+	if err := json.Unmarshal(data, &bans); err != nil {
+		return nil, err
+	}
+	for _, b := range bans {
+		r.bans[b.Target] = b
+	}
+	return r, nil
+}
+
+func (r *FileBanRepository) Save(b *ban.Ban) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bans[b.Target] = b
+	return r.persist()
+}
+
+func (r *FileBanRepository) Delete(target string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.bans[target]; !ok {
+		return errors.New("ban not found")
+	}
+	delete(r.bans, target)
+	return r.persist()
+}
+
+func (r *FileBanRepository) IsBanned(target string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.bans[target]
+	return ok
+}
+
+func (r *FileBanRepository) All() ([]*ban.Ban, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var bans []*ban.Ban
+	for _, b := range r.bans {
+		bans = append(bans, b)
+	}
+	return bans, nil
+}
+
+// persist writes the ban list to a temporary file and renames it over the
+// old one. The caller must hold r.mu.
+func (r *FileBanRepository) persist() error {
+	bans := make([]*ban.Ban, 0, len(r.bans))
+	for _, b := range r.bans {
+		bans = append(bans, b)
+	}
+	data, err := json.MarshalIndent(bans, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, r.path)
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+
+	"tic-tac-toe/internal/domain/ai"
+)
+
+// FileMenaceRepository stores what the MENACE AI has learned in a JSON
+// file, rewritten after every game so its learning survives restarts.
+type FileMenaceRepository struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileMenaceRepository(path string) *FileMenaceRepository {
+	return &FileMenaceRepository{path: path}
+}
+
+func (r *FileMenaceRepository) Load() (ai.MenaceMemory, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var memory ai.MenaceMemory
+	data, err := os.ReadFile(r.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return memory, nil
+	}
+	if err != nil {
+		return memory, err
+	}
+	err = json.Unmarshal(data, &memory)
+	return memory, err
+}
+
+// Save writes memory to a temporary file and renames it over the old one.
+func (r *FileMenaceRepository) Save(memory ai.MenaceMemory) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	data, err := json.MarshalIndent(memory, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, r.path)
+}
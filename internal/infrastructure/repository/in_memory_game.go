@@ -2,11 +2,13 @@ package repository
 
 import (
 	"errors"
+	"sync"
 	"tic-tac-toe/internal/domain/game"
 )
 
 type InMemoryGameRepository struct {
 	games map[string]*game.Game
+	mu    sync.Mutex
 }
 
 func NewInMemoryGameRepository() *InMemoryGameRepository {
@@ -14,19 +16,35 @@ func NewInMemoryGameRepository() *InMemoryGameRepository {
 }
 
 func (r *InMemoryGameRepository) FindByID(id string) (*game.Game, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	g, ok := r.games[id]
 	if !ok {
 		return nil, errors.New("game not found")
 	}
-	return g, nil
+	return g.Clone(), nil
 }
 
 func (r *InMemoryGameRepository) Save(g *game.Game) error {
-	r.games[g.ID] = g
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.games[g.ID] = g.Clone()
 	return nil
 }
 
 func (r *InMemoryGameRepository) Delete(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	delete(r.games, id)
 	return nil
 }
+
+func (r *InMemoryGameRepository) All() ([]*game.Game, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var games []*game.Game
+	for _, g := range r.games {
+		games = append(games, g.Clone())
+	}
+	return games, nil
+}
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"sync"
+	"time"
+
+	"tic-tac-toe/internal/domain/result"
+)
+
+type InMemoryResultRepository struct {
+	results []*result.Result
+	mu      sync.Mutex
+}
+
+func NewInMemoryResultRepository() *InMemoryResultRepository {
+	return &InMemoryResultRepository{}
+}
+
+func (r *InMemoryResultRepository) Save(res *result.Result) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.results = append(r.results, res)
+	return nil
+}
+
+// Since returns every result finished at or after t, oldest first.
+func (r *InMemoryResultRepository) Since(t time.Time) ([]*result.Result, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var results []*result.Result
+	for _, res := range r.results {
+		if !res.FinishedAt.Before(t) {
+			results = append(results, res)
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"tic-tac-toe/internal/domain/season"
+)
+
+type InMemorySeasonRepository struct {
+	seasons map[int]*season.Season
+	mu      sync.Mutex
+}
+
+func NewInMemorySeasonRepository() *InMemorySeasonRepository {
+	return &InMemorySeasonRepository{seasons: make(map[int]*season.Season)}
+}
+
+func (r *InMemorySeasonRepository) Save(s *season.Season) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seasons[s.Number] = s
+	return nil
+}
+
+func (r *InMemorySeasonRepository) FindByNumber(number int) (*season.Season, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.seasons[number]
+	if !ok {
+		return nil, errors.New("season not found")
+	}
+	return s, nil
+}
+
+// All returns the archived seasons ordered by number.
+func (r *InMemorySeasonRepository) All() ([]*season.Season, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var seasons []*season.Season
+	for _, s := range r.seasons {
+		seasons = append(seasons, s)
+	}
+	sort.Slice(seasons, func(i, j int) bool {
+		return seasons[i].Number < seasons[j].Number
+	})
+	return seasons, nil
+}
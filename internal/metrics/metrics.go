@@ -0,0 +1,257 @@
+// Package metrics implements counters, gauges and histograms exposed in the
+// Prometheus text format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	ConnectedPlayers   = NewGauge("ttt_connected_players", "Number of open client connections.")
+	MovesTotal         = NewCounter("ttt_moves_total", "Moves played, by game mode.", "mode")
+	CommandsThrottled  = NewCounter("ttt_commands_throttled_total", "Commands dropped by the rate limiter, by limit scope.", "scope")
+	ConnectionsRefused = NewCounter("ttt_connections_refused_total", "Connections refused by the connection caps.")
+	CommandErrors      = NewCounter("ttt_command_errors_total", "Commands that returned an error, by command and error category.", "command", "error")
+	MatchmakingWait    = NewHistogram("ttt_matchmaking_wait_seconds", "Time players spent in the matchmaking queue before being paired.",
+		[]float64{1, 5, 10, 30, 60, 120, 300, 600})
+	AIMoveDuration = NewHistogram("ttt_ai_move_duration_seconds", "Time the AI took to choose a move, by difficulty.",
+		[]float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1}, "difficulty")
+)
+
+type collector interface {
+	write(w io.Writer)
+}
+
+var (
+	registryMu sync.Mutex
+	registry   []collector
+)
+
+func register(c collector) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry = append(registry, c)
+}
+
+// Handler serves every registered metric.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		registryMu.Lock()
+		collectors := append([]collector(nil), registry...)
+		registryMu.Unlock()
+		for _, c := range collectors {
+			c.write(w)
+		}
+	})
+}
+
+type desc struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+}
+
+func (d desc) writeHeader(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.name, d.help, d.name, d.kind)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// labelKey joins label values so they can key a map.
+func labelKey(values []string) string {
+	return strings.Join(values, "\xff")
+}
+
+func (d desc) formatLabels(key string, extra ...string) string {
+	var pairs []string
+	if len(d.labels) > 0 {
+		for i, value := range strings.Split(key, "\xff") {
+			pairs = append(pairs, d.labels[i]+`="`+labelEscaper.Replace(value)+`"`)
+		}
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, extra[i]+`="`+labelEscaper.Replace(extra[i+1])+`"`)
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func (d desc) checkLabels(values []string) {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", d.name, len(d.labels), len(values)))
+	}
+}
+
+func formatValue(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// values is a set of samples keyed by label values.
+type values struct {
+	desc
+	mu      sync.Mutex
+	samples map[string]float64
+}
+
+func (v *values) add(delta float64, labelValues []string) {
+	v.checkLabels(labelValues)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.samples[labelKey(labelValues)] += delta
+}
+
+func (v *values) set(value float64, labelValues []string) {
+	v.checkLabels(labelValues)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.samples[labelKey(labelValues)] = value
+}
+
+func (v *values) write(w io.Writer) {
+	v.writeHeader(w)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	keys := make([]string, 0, len(v.samples))
+	for key := range v.samples {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(w, "%s%s %s\n", v.name, v.formatLabels(key), formatValue(v.samples[key]))
+	}
+}
+
+type Counter struct {
+	values
+}
+
+func NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{values{desc: desc{name, help, "counter", labels}, samples: make(map[string]float64)}}
+	register(c)
+	return c
+}
+
+func (c *Counter) Inc(labelValues ...string) {
+	c.add(1, labelValues)
+}
+
+type Gauge struct {
+	values
+}
+
+func NewGauge(name, help string, labels ...string) *Gauge {
+	g := &Gauge{values{desc: desc{name, help, "gauge", labels}, samples: make(map[string]float64)}}
+	register(g)
+	return g
+}
+
+func (g *Gauge) Inc(labelValues ...string) {
+	g.add(1, labelValues)
+}
+
+func (g *Gauge) Dec(labelValues ...string) {
+	g.add(-1, labelValues)
+}
+
+func (g *Gauge) Set(value float64, labelValues ...string) {
+	g.set(value, labelValues)
+}
+
+// GaugeFunc is a gauge whose samples are computed at scrape time. The
+// function returns values keyed by the value of the gauge's single label,
+// or by "" when it has none.
+type GaugeFunc struct {
+	desc
+	fn func() map[string]float64
+}
+
+func NewGaugeFunc(name, help string, label string, fn func() map[string]float64) *GaugeFunc {
+	d := desc{name: name, help: help, kind: "gauge"}
+	if label != "" {
+		d.labels = []string{label}
+	}
+	g := &GaugeFunc{desc: d, fn: fn}
+	register(g)
+	return g
+}
+
+func (g *GaugeFunc) write(w io.Writer) {
+	v := &values{desc: g.desc, samples: g.fn()}
+	v.write(w)
+}
+
+type Histogram struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	series  map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	counts []uint64 // cumulative counts per bucket
+	sum    float64
+	count  uint64
+}
+
+func NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	h := &Histogram{
+		desc:    desc{name, help, "histogram", labels},
+		buckets: buckets,
+		series:  make(map[string]*histogramSeries),
+	}
+	register(h)
+	return h
+}
+
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	h.checkLabels(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	key := labelKey(labelValues)
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSeries{counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+	for i, bound := range h.buckets {
+		if value <= bound {
+			s.counts[i]++
+		}
+	}
+	s.sum += value
+	s.count++
+}
+
+func (h *Histogram) write(w io.Writer) {
+	h.writeHeader(w)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	keys := make([]string, 0, len(h.series))
+	for key := range h.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		s := h.series[key]
+		for i, bound := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.formatLabels(key, "le", formatValue(bound)), s.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.formatLabels(key, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, h.formatLabels(key), formatValue(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, h.formatLabels(key), s.count)
+	}
+}
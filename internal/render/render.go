@@ -0,0 +1,165 @@
+package render
+
+import (
+	"strconv"
+	"strings"
+	"tic-tac-toe/internal/domain/game"
+)
+
+// Mode selects how boards are drawn for a connection.
+type Mode string
+
+const (
+	// Plain is the original uncolored text, safe for any client.
+	Plain Mode = "plain"
+	// Color adds ANSI colors, cell numbers and move highlighting.
+	Color Mode = "color"
+	// Redraw is Color with the screen cleared before each board, so the
+	// board stays in place at the top of the terminal.
+	Redraw Mode = "redraw"
+)
+
+// ParseMode returns the mode with the given name.
+func ParseMode(name string) (Mode, bool) {
+	switch mode := Mode(name); mode {
+	case Plain, Color, Redraw:
+		return mode, true
+	}
+	return "", false
+}
+
+// DefaultMode picks the mode for a terminal type reported by the client,
+// such as the TERM of an SSH pty. Unknown and dumb terminals get Plain.
+func DefaultMode(terminal string) Mode {
+	terminal = strings.ToLower(terminal)
+	if terminal == "" || terminal == "dumb" || terminal == "unknown" {
+		return Plain
+	}
+	return Color
+}
+
+const (
+	reset       = "\x1b[0m"
+	colorX      = "\x1b[1;31m"
+	colorO      = "\x1b[1;34m"
+	dim         = "\x1b[2m"
+	lastMove    = "\x1b[7m"
+	winningLine = "\x1b[1;97;42m"
+	clearScreen = "\x1b[H\x1b[2J"
+)
+
+// Frame draws g between header and footer, fitted to a terminal width
+// columns wide (0 if unknown). In Redraw mode the screen is cleared first so
+// the new board replaces the previous one.
+func Frame(g *game.Game, mode Mode, width int, header, footer string) string {
+	frame := header + Board(g, mode, width) + footer
+	if mode == Redraw {
+		frame = clearScreen + frame
+	}
+	return frame
+}
+
+// boardWidth is the width in columns of a full-size board.
+const boardWidth = 11
+
+// Board draws g in the given mode. A terminal narrower than the board gets
+// a compact board without separators and a wider one gets the board
+// centered. Plain output at an unknown width matches
+// game.Game.DisplayString.
+func Board(g *game.Game, mode Mode, width int) string {
+	if width > 0 && width < boardWidth {
+		return compactBoard(g, mode)
+	}
+	var board string
+	if mode != Color && mode != Redraw {
+		board = g.DisplayString()
+	} else {
+		board = colorBoard(g)
+	}
+	if width > boardWidth {
+		board = center(board, width)
+	}
+	return board
+}
+
+func colorBoard(g *game.Game) string {
+	winning := winningCells(g)
+	var sb strings.Builder
+	for row := 0; row < 3; row++ {
+		for col := 0; col < 3; col++ {
+			index := row*3 + col
+			sb.WriteString(cell(g, index, winning[index]))
+			if col < 2 {
+				sb.WriteString(" | ")
+			}
+		}
+		sb.WriteString("\n")
+		if row < 2 {
+			sb.WriteString(dim + "-----------" + reset + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// compactBoard draws each row as three cells with no spacing.
+func compactBoard(g *game.Game, mode Mode) string {
+	winning := winningCells(g)
+	var sb strings.Builder
+	for row := 0; row < 3; row++ {
+		for col := 0; col < 3; col++ {
+			index := row*3 + col
+			if mode == Color || mode == Redraw {
+				sb.WriteString(cell(g, index, winning[index]))
+			} else if g.Board[index] == " " {
+				sb.WriteString(".")
+			} else {
+				sb.WriteString(g.Board[index])
+			}
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func winningCells(g *game.Game) map[int]bool {
+	winning := make(map[int]bool)
+	for _, position := range g.WinningLine() {
+		winning[position] = true
+	}
+	return winning
+}
+
+// center indents every line of a full-size board to the middle of a
+// terminal width columns wide.
+func center(board string, width int) string {
+	indent := strings.Repeat(" ", (width-boardWidth)/2)
+	lines := strings.SplitAfter(board, "\n")
+	var sb strings.Builder
+	for _, line := range lines {
+		if line != "" {
+			sb.WriteString(indent + line)
+		}
+	}
+	return sb.String()
+}
+
+// cell draws one square: an empty square shows its move number, and a mark
+// is colored by symbol and highlighted if it is part of the winning line or
+// the most recent move.
+func cell(g *game.Game, index int, winning bool) string {
+	mark := g.Board[index]
+	switch {
+	case mark == " ":
+		return dim + strconv.Itoa(index+1) + reset
+	case winning:
+		return winningLine + mark + reset
+	}
+	style := colorO
+	if mark == "X" {
+		style = colorX
+	}
+	if index == g.LastMove {
+		style += lastMove
+	}
+	return style + mark + reset
+}
@@ -1,7 +1,63 @@
 package types
 
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"tic-tac-toe/pkg/protocol"
+	"time"
+)
+
+// writeTimeout bounds each write to a client. A client that has not read
+// what it was sent for this long is disconnected, so it cannot hold up the
+// goroutine writing to it, such as an opponent's.
+const writeTimeout = 10 * time.Second
+
+// SendMessage sends text to the player, wrapped in a message event for
+// clients using the JSON protocol.
 func SendMessage(player *Player, message string) {
+	if player.JSON {
+		SendEvent(player, protocol.Event{Type: protocol.EventMessage, Text: message})
+		return
+	}
 	if player.Conn != nil {
-		player.Conn.Write([]byte(message + "\n"))
+		write(player, []byte(message+"\n"))
+	}
+}
+
+// SendEvent sends a typed event to a client using the JSON protocol. Text
+// clients get their information from SendMessage, so it does nothing for
+// them.
+func SendEvent(player *Player, event protocol.Event) {
+	if !player.JSON || player.Conn == nil {
+		return
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		player.Logger().Error("failed to encode event", "type", event.Type, "error", err)
+		return
+	}
+	write(player, append(data, '\n'))
+}
+
+// write sends data to the player's connection within writeTimeout, closing
+// the connection if the client does not take it in time.
+func write(player *Player, data []byte) {
+	player.Conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	_, err := player.Conn.Write(data)
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		player.Logger().Warn("client stopped reading; disconnecting")
+		player.Conn.Close()
+	}
+}
+
+// SendError reports a failed command: "Error: " followed by the error for
+// text clients, an error event for JSON protocol clients.
+func SendError(player *Player, err error) {
+	if player.JSON {
+		SendEvent(player, protocol.Event{Type: protocol.EventError, Text: err.Error()})
+		return
 	}
+	SendMessage(player, "Error: "+err.Error())
 }
@@ -1,22 +1,91 @@
 package types
 
-import "net"
+import (
+	"log/slog"
+	"net"
+	"tic-tac-toe/internal/render"
+	"tic-tac-toe/pkg/protocol"
+	"time"
+)
 
 type Player struct {
 	Conn     net.Conn
 	Username string
 	GameID   string
+	// Display is how boards are drawn for this connection.
+	Display render.Mode
+	// JSON is set once the client switches to the JSON lines protocol.
+	JSON bool
+	// Bot is set when the player logged in as a bot account.
+	Bot bool
+	// Watching is the ID of the game the player is spectating, if any.
+	Watching string
 }
 
 func NewPlayer(conn net.Conn) *Player {
-	return &Player{Conn: conn}
+	return &Player{Conn: conn, Display: render.Plain}
+}
+
+// TerminalWidth returns the width of the client's terminal in columns, or
+// 0 if the connection does not report it.
+func (p *Player) TerminalWidth() int {
+	if sized, ok := p.Conn.(interface{ TerminalWidth() int }); ok {
+		return sized.TerminalWidth()
+	}
+	return 0
+}
+
+// Logger returns a logger carrying the player's username, remote address
+// and current game so entries can be correlated across layers.
+func (p *Player) Logger() *slog.Logger {
+	remoteAddr := ""
+	if p.Conn != nil {
+		remoteAddr = p.Conn.RemoteAddr().String()
+	}
+	return slog.With("username", p.Username, "remote_addr", remoteAddr, "game_id", p.GameID)
 }
 
 type Server interface {
-	AddPlayerToGame(gameID string, player *Player)
+	// AddPlayersToGame seats players in the game. It checks and sets their
+	// GameID under the server's lock and seats none of them if one is
+	// already in a game, so no player ends up in two games at once.
+	AddPlayersToGame(gameID string, players ...*Player) error
+	// BroadcastToGame and BroadcastFunc reach the game's spectators as
+	// well as its players.
 	BroadcastToGame(gameID string, message string)
+	// BroadcastFunc calls send for each player in the game, so what they
+	// receive can follow their display mode and protocol.
+	BroadcastFunc(gameID string, send func(player *Player))
+	// Lobby lists the players online and BroadcastLobby pushes that list to
+	// every JSON protocol client.
+	Lobby() protocol.Lobby
+	BroadcastLobby()
+	// TurnTimeout is how long a player may take over a move, or 0.
+	TurnTimeout() time.Duration
+	// ExhibitionMoveDelay is the pause before each move of an exhibition
+	// game, so spectators can follow it.
+	ExhibitionMoveDelay() time.Duration
+	// Spectate makes player a spectator of the game, leaving any game they
+	// were watching; StopSpectating leaves it. Spectators are dropped when
+	// the game ends.
+	Spectate(gameID string, player *Player)
+	StopSpectating(player *Player)
+	// SpectatorCount returns how many players are watching the game.
+	SpectatorCount(gameID string) int
 	GetPlayer(username string) *Player
 	GetPlayers() map[string]*Player
 	ExitPlayer(player *Player)
 	EndGame(gameID string, message string)
+	IsAdmin(username string) bool
+	Kick(username string, reason string) error
+	Ban(target string, bannedBy string) error
+	Unban(target string) error
+	Broadcast(message string)
+	StartSeason() error
+	QueryAudit(username, gameID string, limit int) ([]string, error)
+	// RegisterBot creates the bot account username, or gives an existing
+	// bot a new token, and returns the token.
+	RegisterBot(username string) (string, error)
+	// SetPassword makes password log in as username.
+	SetPassword(username, password string) error
 }
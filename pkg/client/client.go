@@ -0,0 +1,346 @@
+// Package client connects to a tic-tac-toe server over its JSON protocol
+// and turns the server's events into typed Go values, for writing bots and
+// alternative front ends.
+//
+//	c, err := client.Dial("localhost:5000")
+//	if err != nil { ... }
+//	defer c.Close()
+//	if err := c.LoginBot("mybot", token); err != nil { ... }
+//	c.JoinAI("hard")
+//	for event := range c.Events() {
+//		switch e := event.(type) {
+//		case client.Turn:
+//			c.Move(pickMove(e.Game.Board))
+//		case client.Result:
+//			fmt.Println(e.Outcome)
+//		}
+//	}
+package client
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"tic-tac-toe/pkg/protocol"
+	"time"
+)
+
+// LoginTimeout bounds how long Login waits for the server to answer.
+const LoginTimeout = 10 * time.Second
+
+// Client is a connection to the server. Its methods that send commands may
+// be called from any goroutine; the outcome of a command, including any
+// error, arrives on Events.
+type Client struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	events chan Event
+
+	mu          sync.Mutex // guards writes to conn
+	username    string
+	turnTimeout time.Duration
+	gameID      string
+}
+
+// Dial connects to the server's plaintext port.
+func Dial(addr string) (*Client, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return New(conn), nil
+}
+
+// DialTLS connects to the server's TLS port.
+func DialTLS(addr string, config *tls.Config) (*Client, error) {
+	conn, err := tls.Dial("tcp", addr, config)
+	if err != nil {
+		return nil, err
+	}
+	return New(conn), nil
+}
+
+// New wraps an established connection to the server.
+func New(conn net.Conn) *Client {
+	return &Client{
+		conn:   conn,
+		reader: bufio.NewReader(conn),
+		events: make(chan Event, 64),
+	}
+}
+
+// Login switches the connection to the JSON protocol and logs in as
+// username. After it succeeds, events are delivered on Events.
+func (c *Client) Login(username string) error {
+	return c.login(username)
+}
+
+// LoginBot is Login for a bot account, using the token an admin issued
+// with "admin bot <name>".
+func (c *Client) LoginBot(username, token string) error {
+	return c.login("bot " + username + " " + token)
+}
+
+// LoginPassword is Login for an account protected by a password, set with
+// the "password" command.
+func (c *Client) LoginPassword(username, password string) error {
+	return c.login("login " + username + " " + password)
+}
+
+// login sends the line answering the username prompt and waits for the
+// server to accept it.
+func (c *Client) login(line string) error {
+	if err := c.Send("protocol json"); err != nil {
+		return err
+	}
+	if err := c.Send(line); err != nil {
+		return err
+	}
+	c.conn.SetReadDeadline(time.Now().Add(LoginTimeout))
+	defer c.conn.SetReadDeadline(time.Time{})
+	var early []protocol.Event
+	for {
+		event, err := c.read()
+		if err != nil {
+			return err
+		}
+		switch event.Type {
+		case protocol.EventHello:
+			c.turnTimeout = time.Duration(event.TurnTimeoutMS) * time.Millisecond
+		case protocol.EventError:
+			return errors.New(event.Text)
+		case protocol.EventLogin:
+			c.username = event.Username
+			go c.readLoop(early)
+			return nil
+		default:
+			early = append(early, event)
+		}
+	}
+}
+
+// Username returns the name the client is logged in as.
+func (c *Client) Username() string {
+	return c.username
+}
+
+// TurnTimeout returns how long a player may take over a move, or 0 if the
+// server has no limit.
+func (c *Client) TurnTimeout() time.Duration {
+	return c.turnTimeout
+}
+
+// Events returns the channel of events from the server. It is closed when
+// the connection ends.
+func (c *Client) Events() <-chan Event {
+	return c.events
+}
+
+// JoinTwoPlayer queues for a game against another player.
+func (c *Client) JoinTwoPlayer() error {
+	return c.Send("join two-player")
+}
+
+// JoinAI starts a game against the server's AI. level is a difficulty
+// (easy, medium or hard) or the name of an engine configured on the
+// server; empty uses the server's default.
+func (c *Client) JoinAI(level string) error {
+	if level == "" {
+		return c.Send("join ai")
+	}
+	return c.Send("join ai " + level)
+}
+
+// JoinAIAs is JoinAI with the client playing symbol: X, O or random. X
+// moves first, so as O the game starts with the AI's opening move.
+func (c *Client) JoinAIAs(level, symbol string) error {
+	if level == "" {
+		return c.Send("join ai --as " + symbol)
+	}
+	return c.Send("join ai " + level + " --as " + symbol)
+}
+
+// Challenge invites another player in the lobby to a two-player game.
+func (c *Client) Challenge(username string) error {
+	return c.Send("challenge " + username)
+}
+
+// Accept starts a game against a player whose Challenge event arrived. The
+// challenger moves first.
+func (c *Client) Accept(challenger string) error {
+	return c.Send("accept " + challenger)
+}
+
+func (c *Client) Decline(challenger string) error {
+	return c.Send("decline " + challenger)
+}
+
+// Spectate watches a game in progress, such as an exhibition between two
+// AIs, receiving its Board events until it ends.
+func (c *Client) Spectate(gameID string) error {
+	return c.Send("spectate " + gameID)
+}
+
+// StopSpectating stops watching the game being spectated.
+func (c *Client) StopSpectating() error {
+	return c.Send("spectate off")
+}
+
+// Exhibition starts a game between two AIs, each a difficulty or engine
+// name, and spectates it.
+func (c *Client) Exhibition(levelX, levelO string) error {
+	return c.Send("exhibition " + levelX + " " + levelO)
+}
+
+// Move places the client's mark at position 1 to 9, numbered left to right
+// and top to bottom.
+func (c *Client) Move(position int) error {
+	return c.Send(fmt.Sprintf("move %d", position))
+}
+
+// Say sends a chat line to the client's game, or to the lobby.
+func (c *Client) Say(text string) error {
+	return c.Send("say " + text)
+}
+
+// RequestLobby asks for a Lobby event listing the players online.
+func (c *Client) RequestLobby() error {
+	return c.Send("lobby")
+}
+
+// RequestLeaderboard asks for a Leaderboard event with the top players, or
+// every player if top is 0.
+func (c *Client) RequestLeaderboard(top int) error {
+	if top > 0 {
+		return c.Send(fmt.Sprintf("leaderboard top %d", top))
+	}
+	return c.Send("leaderboard")
+}
+
+// RequestBotLeaderboard asks for a Leaderboard event ranking the players of
+// bot games by rating, limited to the top players if top > 0.
+func (c *Client) RequestBotLeaderboard(top int) error {
+	if top > 0 {
+		return c.Send(fmt.Sprintf("leaderboard bots top %d", top))
+	}
+	return c.Send("leaderboard bots")
+}
+
+// Send sends a raw command line, for commands without a helper.
+func (c *Client) Send(command string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err := c.conn.Write([]byte(command + "\n"))
+	return err
+}
+
+// Close says goodbye to the server and closes the connection.
+func (c *Client) Close() error {
+	c.Send("exit")
+	return c.conn.Close()
+}
+
+// read returns the next protocol event, skipping the text the server sends
+// before the switch to the JSON protocol.
+func (c *Client) read() (protocol.Event, error) {
+	for {
+		line, err := c.reader.ReadBytes('\n')
+		if len(line) > 0 && line[0] == '{' {
+			var event protocol.Event
+			if json.Unmarshal(line, &event) == nil {
+				return event, nil
+			}
+		}
+		if err != nil {
+			return protocol.Event{}, err
+		}
+	}
+}
+
+// readLoop delivers the events received during login and then every later
+// event until the connection ends.
+func (c *Client) readLoop(early []protocol.Event) {
+	defer close(c.events)
+	for _, event := range early {
+		c.dispatch(event)
+	}
+	for {
+		event, err := c.read()
+		if err != nil {
+			return
+		}
+		c.dispatch(event)
+	}
+}
+
+// dispatch converts a protocol event into the typed events it stands for.
+func (c *Client) dispatch(event protocol.Event) {
+	switch event.Type {
+	case protocol.EventMessage:
+		c.events <- Message{Text: event.Text}
+	case protocol.EventError:
+		c.events <- Error{Text: event.Text}
+	case protocol.EventChat:
+		c.events <- Chat{From: event.From, Text: event.Text, Scope: event.Scope}
+	case protocol.EventChallenge:
+		c.events <- Challenge{From: event.From}
+	case protocol.EventLobby:
+		c.events <- Lobby{Players: event.Lobby.Players}
+	case protocol.EventLeaderboard:
+		c.events <- Leaderboard{Standings: event.Standings}
+	case protocol.EventGameEnd:
+		gameID := ""
+		if event.Game != nil {
+			gameID = event.Game.ID
+		}
+		c.events <- GameEnded{GameID: gameID, Reason: event.Text}
+	case protocol.EventGame:
+		c.dispatchGame(event.Game)
+	}
+}
+
+func (c *Client) dispatchGame(g *protocol.Game) {
+	playing := false
+	for _, player := range g.Players {
+		playing = playing || player == c.username
+	}
+	symbol := ""
+	if playing {
+		symbol = g.SymbolOf(c.username)
+	}
+	if g.ID != c.gameID {
+		c.gameID = g.ID
+		opponent := ""
+		for _, player := range g.Players {
+			if playing && player != c.username {
+				opponent = player
+			}
+		}
+		c.events <- GameStarted{Game: g, Symbol: symbol, Opponent: opponent}
+	}
+	c.events <- Board{Game: g}
+	switch {
+	case g.Over():
+		var outcome Outcome
+		switch {
+		case !playing:
+		case g.Winner == c.username:
+			outcome = Win
+		case g.Winner != "":
+			outcome = Loss
+		default:
+			outcome = Draw
+		}
+		c.events <- Result{Game: g, Winner: g.Winner, Outcome: outcome}
+	case g.CurrentTurn == c.username:
+		c.events <- Turn{
+			Game:      g,
+			Symbol:    symbol,
+			Remaining: time.Duration(g.TurnRemainingMS) * time.Millisecond,
+		}
+	}
+}
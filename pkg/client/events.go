@@ -0,0 +1,105 @@
+package client
+
+import (
+	"tic-tac-toe/pkg/protocol"
+	"time"
+)
+
+// Event is one of the event types below, received from Client.Events.
+type Event interface {
+	event()
+}
+
+// GameStarted is sent when the client's new game begins, or it starts
+// spectating a game, before its first Board. Symbol and Opponent are empty
+// for spectators.
+type GameStarted struct {
+	Game     *protocol.Game
+	Symbol   string // the client's mark, X or O
+	Opponent string
+}
+
+// Board is sent with the state of the client's game after it starts and
+// after every move.
+type Board struct {
+	Game *protocol.Game
+}
+
+// Turn is sent after Board when it is the client's move.
+type Turn struct {
+	Game   *protocol.Game
+	Symbol string
+	// Remaining is how long the client has to move, or 0 if there is no
+	// turn limit.
+	Remaining time.Duration
+}
+
+// Outcome is how a finished game went for the client.
+type Outcome string
+
+const (
+	Win  Outcome = "win"
+	Loss Outcome = "loss"
+	Draw Outcome = "draw"
+)
+
+// Result is sent after the final Board of a finished game. Outcome is
+// empty for spectators.
+type Result struct {
+	Game    *protocol.Game
+	Winner  string // empty for a draw
+	Outcome Outcome
+}
+
+// GameEnded is sent when the client leaves its game, because it finished,
+// the opponent left or an admin ended it.
+type GameEnded struct {
+	GameID string
+	Reason string
+}
+
+// Message is human-readable text from the server.
+type Message struct {
+	Text string
+}
+
+// Error reports a command the server rejected, such as an invalid move.
+type Error struct {
+	Text string
+}
+
+// Chat is a chat line from another player. Scope is "game" or "lobby".
+type Chat struct {
+	From  string
+	Text  string
+	Scope string
+}
+
+// Challenge is an invitation to a game from another player; answer it with
+// Client.Accept or Client.Decline.
+type Challenge struct {
+	From string
+}
+
+// Lobby lists the players online.
+type Lobby struct {
+	Players []protocol.LobbyPlayer
+}
+
+// Leaderboard answers Client.RequestLeaderboard and
+// Client.RequestBotLeaderboard.
+type Leaderboard struct {
+	Standings []protocol.Standing
+}
+
+func (GameStarted) event() {}
+func (Board) event()       {}
+func (Turn) event()        {}
+func (Result) event()      {}
+func (GameEnded) event()   {}
+func (Message) event()     {}
+func (Error) event()       {}
+func (Chat) event()        {}
+func (Challenge) event()   {}
+func (Lobby) event()       {}
+func (Leaderboard) event() {}
@@ -0,0 +1,129 @@
+// Package protocol defines the JSON lines protocol the server speaks to
+// clients that send "protocol json", at the username prompt or later. Each
+// line the server writes is then one Event; commands are still sent as
+// plain text lines such as "move 5".
+package protocol
+
+// Version is sent in the hello event and changes whenever an event changes
+// in a way older clients cannot ignore.
+const Version = 1
+
+type EventType string
+
+const (
+	// EventHello confirms the switch to the JSON protocol.
+	EventHello EventType = "hello"
+	// EventMessage carries human-readable text, such as command output that
+	// has no typed event.
+	EventMessage EventType = "message"
+	// EventError reports a failed command or a rejected login.
+	EventError EventType = "error"
+	// EventLogin confirms the username the client is logged in as.
+	EventLogin EventType = "login"
+	// EventGame carries the state of the client's game after it starts and
+	// after every move. A finished game has a Winner or Draw set.
+	EventGame EventType = "game"
+	// EventGameEnd is sent when the client leaves its game, because it
+	// finished or was abandoned. Text says why.
+	EventGameEnd EventType = "game_end"
+	// EventChat is a chat line from another player.
+	EventChat EventType = "chat"
+	// EventLobby lists the players online. It is pushed whenever a player
+	// logs in or out or starts or finishes a game.
+	EventLobby EventType = "lobby"
+	// EventLeaderboard answers the leaderboard command.
+	EventLeaderboard EventType = "leaderboard"
+	// EventChallenge is sent when another player challenges the client to
+	// a game. From is the challenger; answer with "accept <from>" or
+	// "decline <from>".
+	EventChallenge EventType = "challenge"
+)
+
+// Event is one line of server output.
+type Event struct {
+	Type EventType `json:"type"`
+	// Version and TurnTimeoutMS are set on hello. TurnTimeoutMS is how
+	// long a player may take over a move, or 0 if there is no limit.
+	Version       int   `json:"version,omitempty"`
+	TurnTimeoutMS int64 `json:"turn_timeout_ms,omitempty"`
+	// Text is set on message, error, chat and game_end.
+	Text string `json:"text,omitempty"`
+	// Username is set on login.
+	Username string `json:"username,omitempty"`
+	// From and Scope are set on chat, and From on challenge. Scope is
+	// "game" or "lobby".
+	From  string `json:"from,omitempty"`
+	Scope string `json:"scope,omitempty"`
+
+	Game      *Game      `json:"game,omitempty"`
+	Lobby     *Lobby     `json:"lobby,omitempty"`
+	Standings []Standing `json:"standings,omitempty"`
+}
+
+// Game is the state of one game. Positions are numbered 1 to 9 as in the
+// move command, left to right and top to bottom. Mode is "ai",
+// "two-player" or "exhibition", a game between two AIs.
+type Game struct {
+	ID      string   `json:"id"`
+	Mode    string   `json:"mode"`
+	Players []string `json:"players"`
+	// Board holds "X", "O" or " " for positions 1 to 9.
+	Board       [9]string `json:"board"`
+	CurrentTurn string    `json:"current_turn,omitempty"`
+	// LastMove is the position of the most recent mark, or 0.
+	LastMove    int    `json:"last_move,omitempty"`
+	Winner      string `json:"winner,omitempty"`
+	Draw        bool   `json:"draw,omitempty"`
+	WinningLine []int  `json:"winning_line,omitempty"`
+	// TurnRemainingMS is how long CurrentTurn has left to move when the
+	// event was sent, or 0 if there is no turn limit.
+	TurnRemainingMS int64 `json:"turn_remaining_ms,omitempty"`
+}
+
+// Over reports whether the game has finished.
+func (g *Game) Over() bool {
+	return g.Winner != "" || g.Draw
+}
+
+// SymbolOf returns the mark placed by username: X for the first player and
+// O for the second.
+func (g *Game) SymbolOf(username string) string {
+	if len(g.Players) > 0 && g.Players[0] == username {
+		return "X"
+	}
+	return "O"
+}
+
+// Lobby lists the players online, sorted by username.
+type Lobby struct {
+	Players []LobbyPlayer `json:"players"`
+}
+
+// Player statuses in the lobby.
+const (
+	StatusIdle    = "idle"
+	StatusWaiting = "waiting"
+	StatusPlaying = "playing"
+	// StatusWatching players are spectating the game in GameID.
+	StatusWatching = "watching"
+)
+
+type LobbyPlayer struct {
+	Username string `json:"username"`
+	Status   string `json:"status"`
+	GameID   string `json:"game_id,omitempty"`
+	// Bot is set for bot accounts.
+	Bot bool `json:"bot,omitempty"`
+}
+
+// Standing is one row of a leaderboard, best first. Rating is only set on
+// the bot leaderboard, which ranks by it instead of Points.
+type Standing struct {
+	Rank      int    `json:"rank"`
+	Username  string `json:"username"`
+	Points    int    `json:"points"`
+	Wins      int    `json:"wins"`
+	WinStreak int    `json:"win_streak"`
+	Rating    int    `json:"rating,omitempty"`
+	Bot       bool   `json:"bot,omitempty"`
+}